@@ -0,0 +1,222 @@
+// Package busfactor estimates how many people would have to leave before
+// code is orphaned, from how much each author contributed to each file.
+package busfactor
+
+import (
+	"path"
+	"sort"
+
+	"techdebt/components/commitinfo"
+)
+
+// DefaultOwnershipThreshold is the share of the top author's weight an
+// author needs to count as an owner of a file.
+const DefaultOwnershipThreshold = 0.75
+
+// Matrix is the author×file ownership matrix: the weight of each author's
+// contributions to each file.
+//
+//	{
+//	 filename1: {author1: 1, author2: 6},
+//	 filename2: {author0: 5, author1: 3}
+//	}
+type Matrix map[string]map[string]float64
+
+// FromCommits builds the matrix of a history.
+func FromCommits(commits []commitinfo.CommitInfo) Matrix {
+	m := make(Matrix)
+	for _, commit := range commits {
+		m.Add(commit)
+	}
+	return m
+}
+
+// Add counts the weight of one commit record.
+func (m Matrix) Add(commit commitinfo.CommitInfo) {
+	if m[commit.Filename] == nil {
+		m[commit.Filename] = make(map[string]float64)
+	}
+	m[commit.Filename][commit.Author] += commit.Weight
+}
+
+// Owners returns the authors whose weight in file is at least threshold
+// times the top author's, sorted by name. A threshold of 0 uses
+// DefaultOwnershipThreshold.
+func (m Matrix) Owners(file string, threshold float64) []string {
+	if threshold <= 0 {
+		threshold = DefaultOwnershipThreshold
+	}
+	var top float64
+	for _, weight := range m[file] {
+		top = max(top, weight)
+	}
+	if top <= 0 {
+		return nil
+	}
+
+	var owners []string
+	for author, weight := range m[file] {
+		if weight >= threshold*top {
+			owners = append(owners, author)
+		}
+	}
+	sort.Strings(owners)
+	return owners
+}
+
+// Result is the truck factor of a set of files.
+type Result struct {
+	// TruckFactor is the number of authors whose departure orphans more
+	// than half of the files.
+	TruckFactor int
+	// Authors are those authors, in the order the greedy search removed
+	// them.
+	Authors []string
+	// Files is the number of files considered and Orphaned the number
+	// left without an owner once Authors are gone.
+	Files    int
+	Orphaned int
+}
+
+// TruckFactor runs the greedy author-removal algorithm: it keeps removing
+// the author who owns the most files that still have an owner, until more
+// than half of the files have none left. Ties go to the author whose name
+// sorts first. The threshold is passed to Owners.
+func TruckFactor(m Matrix, threshold float64) Result {
+	result := Result{Files: len(m)}
+	if len(m) == 0 {
+		return result
+	}
+
+	owners := make(map[string][]string, len(m))
+	for file := range m {
+		owners[file] = m.Owners(file, threshold)
+	}
+
+	removed := make(map[string]bool)
+	orphaned := func(file string) bool {
+		for _, owner := range owners[file] {
+			if !removed[owner] {
+				return false
+			}
+		}
+		return true
+	}
+
+	for 2*result.Orphaned <= result.Files {
+		// count the files each remaining author still keeps alive
+		covered := make(map[string]int)
+		for file := range m {
+			if orphaned(file) {
+				continue
+			}
+			for _, owner := range owners[file] {
+				if !removed[owner] {
+					covered[owner]++
+				}
+			}
+		}
+
+		next, most := "", 0
+		for author, count := range covered {
+			if count > most || (count == most && author < next) {
+				next, most = author, count
+			}
+		}
+		if next == "" {
+			break
+		}
+
+		removed[next] = true
+		result.Authors = append(result.Authors, next)
+		result.Orphaned = 0
+		for file := range m {
+			if orphaned(file) {
+				result.Orphaned++
+			}
+		}
+	}
+
+	result.TruckFactor = len(result.Authors)
+	return result
+}
+
+// DirectoryResult is the truck factor of the files below a directory.
+type DirectoryResult struct {
+	Dir string
+	Result
+}
+
+// ByDirectory computes the truck factor of every directory from the files
+// anywhere below it, sorted by directory. Files at the top level belong to
+// the directory ".", which therefore gives the truck factor of the repo.
+func ByDirectory(m Matrix, threshold float64) []DirectoryResult {
+	dirs := make(map[string]Matrix)
+	for file, authors := range m {
+		for dir := path.Dir(file); ; dir = path.Dir(dir) {
+			if dirs[dir] == nil {
+				dirs[dir] = make(Matrix)
+			}
+			dirs[dir][file] = authors
+			if dir == "." || dir == "/" {
+				break
+			}
+		}
+	}
+
+	results := make([]DirectoryResult, 0, len(dirs))
+	for dir, files := range dirs {
+		results = append(results, DirectoryResult{Dir: dir, Result: TruckFactor(files, threshold)})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return dirLess(results[i].Dir, results[j].Dir)
+	})
+	return results
+}
+
+// dirLess sorts "." first and the rest by path.
+func dirLess(a, b string) bool {
+	if a == "." || b == "." {
+		return a == "." && b != "."
+	}
+	return a < b
+}
+
+// File is the bus factor of a single file: the smallest number of authors
+// who together made more than half of its changes.
+func File(weights map[string]float64) int {
+	shares := make([]float64, 0, len(weights))
+	var total float64
+	for _, weight := range weights {
+		shares = append(shares, weight)
+		total += weight
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(shares)))
+
+	var covered float64
+	for i, share := range shares {
+		covered += share
+		if covered > total/2 {
+			return i + 1
+		}
+	}
+	return len(shares)
+}
+
+// FileResult is the bus factor of one file.
+type FileResult struct {
+	Filename  string
+	BusFactor int
+}
+
+// Files computes the bus factor of every file, sorted by filename.
+func Files(m Matrix) []FileResult {
+	files := make([]FileResult, 0, len(m))
+	for file, weights := range m {
+		files = append(files, FileResult{Filename: file, BusFactor: File(weights)})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Filename < files[j].Filename
+	})
+	return files
+}
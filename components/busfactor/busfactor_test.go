@@ -0,0 +1,64 @@
+package busfactor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"techdebt/components/commitinfo"
+)
+
+func TestOwners(t *testing.T) {
+	m := Matrix{"a.go": {"alice": 10, "bob": 8, "carol": 7}}
+	assert.Equal(t, []string{"alice", "bob"}, m.Owners("a.go", 0))
+	assert.Equal(t, []string{"alice", "bob", "carol"}, m.Owners("a.go", 0.5))
+	assert.Nil(t, m.Owners("missing.go", 0))
+}
+
+func TestTruckFactor(t *testing.T) {
+	m := FromCommits([]commitinfo.CommitInfo{
+		{Author: "alice", Filename: "core/a.go", Weight: 5},
+		{Author: "alice", Filename: "core/b.go", Weight: 5},
+		{Author: "alice", Filename: "core/c.go", Weight: 5},
+		{Author: "bob", Filename: "core/c.go", Weight: 1},
+		{Author: "bob", Filename: "web/d.go", Weight: 5},
+		{Author: "carol", Filename: "web/d.go", Weight: 4},
+		{Author: "carol", Filename: "web/e.go", Weight: 2},
+	})
+
+	// alice alone owns three of five files
+	assert.Equal(t, Result{TruckFactor: 1, Authors: []string{"alice"}, Files: 5, Orphaned: 3}, TruckFactor(m, 0))
+
+	dirs := ByDirectory(m, 0)
+	assert.Equal(t, []DirectoryResult{
+		{Dir: ".", Result: Result{TruckFactor: 1, Authors: []string{"alice"}, Files: 5, Orphaned: 3}},
+		{Dir: "core", Result: Result{TruckFactor: 1, Authors: []string{"alice"}, Files: 3, Orphaned: 3}},
+		// carol owns both web files, but bob shares d.go with her and one
+		// of two files is not more than half
+		{Dir: "web", Result: Result{TruckFactor: 2, Authors: []string{"carol", "bob"}, Files: 2, Orphaned: 2}},
+	}, dirs)
+
+	assert.Equal(t, Result{}, TruckFactor(Matrix{}, 0))
+}
+
+func TestTruckFactorShared(t *testing.T) {
+	// every file is owned by two people, so two have to leave
+	m := Matrix{
+		"a.go": {"alice": 1, "bob": 1},
+		"b.go": {"alice": 1, "bob": 1},
+		"c.go": {"carol": 1, "dave": 1},
+	}
+	assert.Equal(t, Result{TruckFactor: 2, Authors: []string{"alice", "bob"}, Files: 3, Orphaned: 2}, TruckFactor(m, 0))
+}
+
+func TestFileBusFactor(t *testing.T) {
+	assert.Equal(t, 1, File(map[string]float64{"alice": 6, "bob": 4}))
+	assert.Equal(t, 2, File(map[string]float64{"alice": 5, "bob": 5}))
+	assert.Equal(t, 3, File(map[string]float64{"a": 3, "b": 3, "c": 3, "d": 3}))
+	assert.Equal(t, 0, File(nil))
+
+	assert.Equal(t, []FileResult{{"a.go", 2}, {"b.go", 1}}, Files(Matrix{
+		"b.go": {"alice": 1},
+		"a.go": {"alice": 1, "bob": 1},
+	}))
+}
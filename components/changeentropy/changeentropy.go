@@ -0,0 +1,235 @@
+// Package changeentropy measures how scattered changes are across files
+// over time, after Hassan's "Predicting faults using the complexity of code
+// changes" (ICSE 2009): periods in which changes are spread over many files
+// are harder to keep track of, and the files changed in them are more
+// likely to have defects.
+package changeentropy
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"techdebt/components/commitinfo"
+	"techdebt/components/entropy"
+)
+
+// Period is a stretch of history and the changes made in it.
+type Period struct {
+	// Index is the position of the period in the history. ByInterval leaves
+	// out periods without changes, so indices may skip some.
+	Index      int
+	Start, End time.Time
+	// Changes sums the weight of the records of each file changed in the
+	// period.
+	Changes map[string]float64
+	// Entropy is the Shannon entropy of the distribution of Changes over
+	// files, normalized by the maximum entropy of the files changed in the
+	// whole history, so it lies between 0 and 1.
+	Entropy float64
+}
+
+// ByInterval splits a history into consecutive periods of the given length,
+// starting at its oldest record. Only periods with changes are returned, but
+// their Index counts the empty ones too, so that the decayed metrics see how
+// far back a period lies however short the length.
+func ByInterval(commits []commitinfo.CommitInfo, length time.Duration) []Period {
+	if length <= 0 || len(commits) == 0 {
+		return nil
+	}
+	sorted := byTime(commits)
+
+	start := sorted[0].Timestamp
+	var periods []Period
+	for _, commit := range sorted {
+		i := int(commit.Timestamp.Sub(start) / length)
+		if len(periods) == 0 || periods[len(periods)-1].Index != i {
+			periods = append(periods, Period{
+				Index:   i,
+				Start:   start.Add(time.Duration(i) * length),
+				End:     start.Add(time.Duration(i+1) * length),
+				Changes: make(map[string]float64),
+			})
+		}
+		periods[len(periods)-1].Changes[commit.Filename] += commit.Weight
+	}
+	return withEntropy(periods)
+}
+
+// ByBurst splits a history into bursts of activity: a new period starts
+// whenever no record was made for longer than gap. A period ends with its
+// last record.
+func ByBurst(commits []commitinfo.CommitInfo, gap time.Duration) []Period {
+	if len(commits) == 0 {
+		return nil
+	}
+
+	var periods []Period
+	for _, commit := range byTime(commits) {
+		if len(periods) == 0 || commit.Timestamp.Sub(periods[len(periods)-1].End) > gap {
+			periods = append(periods, Period{Index: len(periods), Start: commit.Timestamp, Changes: make(map[string]float64)})
+		}
+		period := &periods[len(periods)-1]
+		period.End = commit.Timestamp
+		period.Changes[commit.Filename] += commit.Weight
+	}
+	return withEntropy(periods)
+}
+
+// Count returns the number of periods of the history periods were split
+// from, including the empty ones ByInterval leaves out.
+func Count(periods []Period) int {
+	if len(periods) == 0 {
+		return 0
+	}
+	return periods[len(periods)-1].Index + 1
+}
+
+// byTime returns a copy of commits sorted from oldest to newest.
+func byTime(commits []commitinfo.CommitInfo) []commitinfo.CommitInfo {
+	sorted := append([]commitinfo.CommitInfo(nil), commits...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+	return sorted
+}
+
+// withEntropy fills in the Entropy of every period.
+func withEntropy(periods []Period) []Period {
+	files := make(map[string]bool)
+	for _, period := range periods {
+		for file := range period.Changes {
+			files[file] = true
+		}
+	}
+
+	for i, period := range periods {
+		weights := make([]float64, 0, len(period.Changes))
+		for _, weight := range period.Changes {
+			weights = append(weights, weight)
+		}
+		h := float64(entropy.CalculateEntropyOfWeights(weights))
+		periods[i].Entropy = entropy.NormalizeEntropy(h, len(files))
+	}
+	return periods
+}
+
+// Contribution is how much of a period's entropy a file changed in it is
+// charged with.
+type Contribution int
+
+const (
+	// ContributionFull charges every changed file with the whole entropy
+	// (Hassan's HCM1s).
+	ContributionFull Contribution = iota
+	// ContributionShare charges a file with its share of the period's
+	// changes (HCM2s).
+	ContributionShare
+	// ContributionEven splits the entropy evenly between the changed files
+	// (HCM3s).
+	ContributionEven
+)
+
+var contributionNames = map[Contribution]string{
+	ContributionFull:  "full",
+	ContributionShare: "share",
+	ContributionEven:  "even",
+}
+
+func (c Contribution) String() string {
+	if name, ok := contributionNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("Contribution(%d)", int(c))
+}
+
+// ParseContribution parses "full", "share" or "even".
+func ParseContribution(s string) (Contribution, error) {
+	for contribution, name := range contributionNames {
+		if name == s {
+			return contribution, nil
+		}
+	}
+	return ContributionFull, fmt.Errorf("unknown contribution %q, want full, share or even", s)
+}
+
+// of returns the History Complexity Period Factor of file in period, or 0
+// if the period did not change it.
+func (c Contribution) of(period Period, file string) float64 {
+	weight, ok := period.Changes[file]
+	if !ok {
+		return 0
+	}
+	switch c {
+	case ContributionShare:
+		var total float64
+		for _, w := range period.Changes {
+			total += w
+		}
+		if total <= 0 {
+			return 0
+		}
+		return weight / total * period.Entropy
+	case ContributionEven:
+		return period.Entropy / float64(len(period.Changes))
+	default:
+		return period.Entropy
+	}
+}
+
+// Score is the History Complexity Metric of a file and its decayed
+// variants, which weigh down periods further in the past.
+type Score struct {
+	Filename string
+	// Periods is the number of periods that changed the file.
+	Periods int
+	// HCM sums the file's period factors.
+	HCM float64
+	// EDHCM divides the factor of the i-th of n periods by e^(φ(n-i)),
+	// LDHCM by φ(n+1-i) and LGDHCM by φ·ln(n+1.01-i).
+	EDHCM  float64
+	LDHCM  float64
+	LGDHCM float64
+}
+
+// Scores computes the metrics of every file changed in periods, which are
+// in chronological order, with decay factor phi; a phi of 0 uses 1. Files
+// are ranked by HCM, highest first, then by name.
+func Scores(periods []Period, contribution Contribution, phi float64) []Score {
+	if phi <= 0 {
+		phi = 1
+	}
+
+	scores := make(map[string]*Score)
+	n := float64(Count(periods))
+	for _, period := range periods {
+		// i counts from 1 in the paper
+		age := n - float64(period.Index+1)
+		for file := range period.Changes {
+			score := scores[file]
+			if score == nil {
+				score = &Score{Filename: file}
+				scores[file] = score
+			}
+			factor := contribution.of(period, file)
+			score.Periods++
+			score.HCM += factor
+			score.EDHCM += factor / math.Exp(phi*age)
+			score.LDHCM += factor / (phi * (age + 1))
+			score.LGDHCM += factor / (phi * math.Log(age+1.01))
+		}
+	}
+
+	ranked := make([]Score, 0, len(scores))
+	for _, score := range scores {
+		ranked = append(ranked, *score)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].HCM != ranked[j].HCM {
+			return ranked[i].HCM > ranked[j].HCM
+		}
+		return ranked[i].Filename < ranked[j].Filename
+	})
+	return ranked
+}
@@ -0,0 +1,108 @@
+package changeentropy
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"techdebt/components/commitinfo"
+)
+
+var t0 = time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+// history changes a.go and b.go in a first burst and c.go three days later,
+// newest first like the history streams.
+var history = []commitinfo.CommitInfo{
+	{Filename: "c.go", Timestamp: t0.Add(72 * time.Hour), Weight: 1},
+	{Filename: "a.go", Timestamp: t0.Add(time.Hour), Weight: 1},
+	{Filename: "a.go", Timestamp: t0, Weight: 1},
+	{Filename: "b.go", Timestamp: t0, Weight: 1},
+}
+
+// firstEntropy is the entropy of changes split 2:1 between two of three
+// files. Entropies are computed in float32, hence the deltas.
+var firstEntropy = (2.0/3*math.Log2(3.0/2) + 1.0/3*math.Log2(3)) / math.Log2(3)
+
+func TestByInterval(t *testing.T) {
+	periods := ByInterval(history, 48*time.Hour)
+	require.Len(t, periods, 2)
+	assert.Equal(t, t0, periods[0].Start)
+	assert.Equal(t, t0.Add(96*time.Hour), periods[1].End)
+	assert.Equal(t, map[string]float64{"a.go": 2, "b.go": 1}, periods[0].Changes)
+	assert.InDelta(t, firstEntropy, periods[0].Entropy, 1e-6)
+	assert.Equal(t, 0.0, periods[1].Entropy)
+
+	// the empty days in between are left out but counted
+	periods = ByInterval(history, 24*time.Hour)
+	require.Len(t, periods, 2)
+	assert.Equal(t, 3, periods[1].Index)
+	assert.Equal(t, t0.Add(72*time.Hour), periods[1].Start)
+	assert.Equal(t, 4, Count(periods))
+	assert.Nil(t, ByInterval(nil, time.Hour))
+
+	// a second over years only makes the periods with changes
+	years := []commitinfo.CommitInfo{
+		{Filename: "a.go", Timestamp: t0, Weight: 1},
+		{Filename: "a.go", Timestamp: t0.AddDate(10, 0, 0), Weight: 1},
+	}
+	periods = ByInterval(years, time.Second)
+	require.Len(t, periods, 2)
+	assert.Equal(t, int(t0.AddDate(10, 0, 0).Sub(t0)/time.Second)+1, Count(periods))
+}
+
+func TestByBurst(t *testing.T) {
+	periods := ByBurst(history, 12*time.Hour)
+	require.Len(t, periods, 2)
+	assert.Equal(t, t0, periods[0].Start)
+	assert.Equal(t, t0.Add(time.Hour), periods[0].End)
+	assert.Equal(t, map[string]float64{"a.go": 2, "b.go": 1}, periods[0].Changes)
+	assert.InDelta(t, firstEntropy, periods[0].Entropy, 1e-6)
+
+	assert.Len(t, ByBurst(history, 30*time.Minute), 3)
+	assert.Len(t, ByBurst(history, 100*time.Hour), 1)
+}
+
+func TestScores(t *testing.T) {
+	periods := ByBurst(history, 12*time.Hour)
+
+	scores := Scores(periods, ContributionFull, 0)
+	require.Len(t, scores, 3)
+	assert.Equal(t, []string{"a.go", "b.go", "c.go"},
+		[]string{scores[0].Filename, scores[1].Filename, scores[2].Filename})
+	a := scores[0]
+	assert.Equal(t, 1, a.Periods)
+	assert.InDelta(t, firstEntropy, a.HCM, 1e-6)
+	// the first of two periods is one period old
+	assert.InDelta(t, firstEntropy/math.E, a.EDHCM, 1e-6)
+	assert.InDelta(t, firstEntropy/2, a.LDHCM, 1e-6)
+	assert.InDelta(t, firstEntropy/math.Log(2.01), a.LGDHCM, 1e-6)
+	assert.Equal(t, Score{Filename: "c.go", Periods: 1}, scores[2])
+
+	scores = Scores(periods, ContributionShare, 2)
+	assert.InDelta(t, firstEntropy*2/3, scores[0].HCM, 1e-6)
+	assert.InDelta(t, firstEntropy*2/3/math.Exp(2), scores[0].EDHCM, 1e-6)
+	assert.InDelta(t, firstEntropy/3, scores[1].HCM, 1e-6)
+
+	scores = Scores(periods, ContributionEven, 1)
+	assert.InDelta(t, firstEntropy/2, scores[0].HCM, 1e-6)
+	assert.InDelta(t, firstEntropy/2, scores[1].HCM, 1e-6)
+
+	// the first day is three days old, the empty ones in between count
+	scores = Scores(ByInterval(history, 24*time.Hour), ContributionFull, 1)
+	assert.InDelta(t, firstEntropy/math.Exp(3), scores[0].EDHCM, 1e-6)
+	assert.InDelta(t, firstEntropy/4, scores[0].LDHCM, 1e-6)
+	assert.Empty(t, Scores(nil, ContributionFull, 1))
+}
+
+func TestParseContribution(t *testing.T) {
+	for _, c := range []Contribution{ContributionFull, ContributionShare, ContributionEven} {
+		parsed, err := ParseContribution(c.String())
+		assert.NoError(t, err)
+		assert.Equal(t, c, parsed)
+	}
+	_, err := ParseContribution("half")
+	assert.Error(t, err)
+}
@@ -7,9 +7,27 @@ import (
 )
 
 type CommitInfo struct {
-	Author    string
-	Filename  string
-	Timestamp time.Time
+	// Hash identifies the commit the record belongs to.
+	Hash string
+	// Author and Email are the canonical identity of the commit author,
+	// after .mailmap and alias resolution.
+	Author string
+	Email  string
+	// OriginalAuthor and OriginalEmail are recorded in the commit itself.
+	OriginalAuthor string
+	OriginalEmail  string
+	Filename       string
+	// OriginalFilename is the path the file had in this commit, which
+	// differs from Filename when the file has been renamed since.
+	OriginalFilename string
+	Timestamp        time.Time
+	// Weight is the share of credit for the change, 1 unless the commit's
+	// credit is split between co-authors.
+	Weight float64
+	// LinesAdded and LinesDeleted count the lines the commit changed in the
+	// file. They are only filled in when line stats are requested.
+	LinesAdded   int
+	LinesDeleted int
 }
 
 func (c *CommitInfo) Print() {
@@ -60,3 +60,50 @@ func CalculateEntropyOfCounts(data []int) helpers.TypeProb {
 
 	return helpers.TypeProb(CalculateEntropyOfProbabilities(probs))
 }
+
+// CalculateEntropyOfWeights calculates the entropy of weighted counts, such
+// as commit counts where some commits only carry part of the credit.
+func CalculateEntropyOfWeights(data []float64) helpers.TypeProb {
+	if len(data) == 0 {
+		return 0.0
+	}
+
+	return CalculateEntropyOfProbabilities(helpers.MakeProbabilitiesFromWeights(data))
+}
+
+// EntropyOfOccurrences is the generic counterpart of
+// CalculateEntropyOfOccurances: the entropy, in float64, of how often each
+// value occurs in data, e.g. of the author names of a list of commits.
+func EntropyOfOccurrences[K comparable](data []K) float64 {
+	return EntropyOfCounts(helpers.Counts(data))
+}
+
+// EntropyOfCounts is the generic counterpart of CalculateEntropyOfCounts
+// and CalculateEntropyOfWeights: the entropy, in float64, of counts or
+// weights keyed by outcome, e.g. commits by author name.
+func EntropyOfCounts[K comparable, N helpers.Number](counts map[K]N) float64 {
+	var entropy float64
+	for _, p := range helpers.Probabilities(counts) {
+		if p > 0 {
+			entropy -= p * math.Log2(p)
+		}
+	}
+	return entropy
+}
+
+// NormalizeEntropy divides entropy by its maximum log2(n) for n outcomes,
+// giving the evenness of the distribution: 0 when one outcome has it all,
+// 1 when all n are equally likely. It is 0 for fewer than two outcomes.
+func NormalizeEntropy(entropy float64, n int) float64 {
+	if n < 2 {
+		return 0
+	}
+	return entropy / math.Log2(float64(n))
+}
+
+// EffectiveNumber is 2^entropy, the number of equally likely outcomes that
+// would have the same entropy, e.g. the number of authors contributing
+// evenly that a file's ownership is worth.
+func EffectiveNumber(entropy float64) float64 {
+	return math.Exp2(entropy)
+}
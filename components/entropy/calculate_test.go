@@ -57,3 +57,31 @@ func TestEntropyCalc(t *testing.T) {
 	assert.EqualValues(t, expected, actual, "they should be equal")
 
 }
+
+func TestEntropyOfWeights(t *testing.T) {
+	var expected, actual helpers.TypeProb
+
+	actual = CalculateEntropyOfWeights([]float64{})
+	expected = 0.0
+	assert.EqualValues(t, expected, actual, "they should be equal")
+
+	// split credit gives the same entropy as the equivalent whole counts
+	actual = CalculateEntropyOfWeights([]float64{0.5, 0.5, 3})
+	expected = CalculateEntropyOfCounts([]int{1, 1, 6})
+	assert.EqualValues(t, expected, actual, "they should be equal")
+
+}
+
+func TestGenericEntropy(t *testing.T) {
+	assert.Equal(t, 0.0, EntropyOfOccurrences([]string{}))
+	assert.Equal(t, 1.0, EntropyOfOccurrences([]string{"alice", "bob"}))
+	// sparse ids cost no more than dense ones
+	assert.InDelta(t, float64(CalculateEntropyOfOccurances([]int{0, 0, 1, 2})),
+		EntropyOfOccurrences([]int{-7, -7, 1 << 40, 3}), 1e-6)
+
+	assert.InDelta(t, float64(CalculateEntropyOfCounts([]int{1, 1, 6})),
+		EntropyOfCounts(map[string]int{"alice": 1, "bob": 1, "carol": 6}), 1e-6)
+	assert.InDelta(t, float64(CalculateEntropyOfWeights([]float64{0.5, 0.5, 3})),
+		EntropyOfCounts(map[string]float64{"alice": 0.5, "bob": 0.5, "carol": 3}), 1e-6)
+	assert.Equal(t, 0.0, EntropyOfCounts(map[string]float64{"alice": 0}))
+}
@@ -0,0 +1,30 @@
+package entropy
+
+import (
+	"math"
+	"time"
+)
+
+// Decay weighs down old contributions exponentially: a contribution loses
+// half of its weight every HalfLife between its timestamp and Now. A zero
+// HalfLife disables decay.
+//
+// Entropy only depends on the ratios between weights, so the choice of Now
+// does not change it as long as all weights share it.
+type Decay struct {
+	HalfLife time.Duration
+	Now      time.Time
+}
+
+// Factor returns the share of its weight a contribution made at when keeps.
+// Contributions dated after Now, e.g. because of clock skew, keep all of it.
+func (d Decay) Factor(when time.Time) float64 {
+	if d.HalfLife <= 0 {
+		return 1
+	}
+	age := d.Now.Sub(when)
+	if age <= 0 {
+		return 1
+	}
+	return math.Exp2(-float64(age) / float64(d.HalfLife))
+}
@@ -0,0 +1,21 @@
+package entropy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecayFactor(t *testing.T) {
+	now := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	decay := Decay{HalfLife: 30 * 24 * time.Hour, Now: now}
+
+	assert.Equal(t, 1.0, decay.Factor(now))
+	assert.InDelta(t, 0.5, decay.Factor(now.AddDate(0, 0, -30)), 1e-9)
+	assert.InDelta(t, 0.125, decay.Factor(now.AddDate(0, 0, -90)), 1e-9)
+	// clock skew does not inflate weights
+	assert.Equal(t, 1.0, decay.Factor(now.AddDate(0, 0, 1)))
+	// no half-life, no decay
+	assert.Equal(t, 1.0, Decay{Now: now}.Factor(now.AddDate(-5, 0, 0)))
+}
@@ -1,12 +1,40 @@
 package entropy
 
 import (
+	"fmt"
 	"sort"
 )
 
 type FileEntropy struct {
 	Filename string  `json:"filename"`
 	Entropy  float64 `json:"entropy"`
+	// Authors is the number of authors with a share of the file.
+	Authors int `json:"authors"`
+	// Normalized is Entropy divided by its maximum for Authors authors, so
+	// that files with different numbers of authors can be compared.
+	Normalized float64 `json:"normalized"`
+	// EffectiveAuthors is the number of evenly contributing authors the
+	// file's Entropy corresponds to.
+	EffectiveAuthors float64 `json:"effective_authors"`
+}
+
+// NewFileEntropy computes the entropy columns of a file from the weights of
+// its authors. Authors with a zero weight do not count.
+func NewFileEntropy(filename string, weights []float64) FileEntropy {
+	authors := 0
+	for _, w := range weights {
+		if w > 0 {
+			authors++
+		}
+	}
+	h := float64(CalculateEntropyOfWeights(weights))
+	return FileEntropy{
+		Filename:         filename,
+		Entropy:          h,
+		Authors:          authors,
+		Normalized:       NormalizeEntropy(h, authors),
+		EffectiveAuthors: EffectiveNumber(h),
+	}
 }
 
 func SortByFilename(files []FileEntropy) {
@@ -16,8 +44,111 @@ func SortByFilename(files []FileEntropy) {
 }
 
 // SortByEntropy sorts a slice of FileEntropy by Entropy in ascending order.
+// Ties are sorted by filename.
 func SortByEntropy(files []FileEntropy) {
-	sort.Slice(files, func(i, j int) bool {
-		return files[i].Entropy < files[j].Entropy
+	sortByValue(files, func(fe FileEntropy) float64 { return fe.Entropy })
+}
+
+// SortByNormalized sorts a slice of FileEntropy by Normalized in ascending
+// order, most concentrated ownership first. Ties are sorted by filename.
+func SortByNormalized(files []FileEntropy) {
+	sortByValue(files, func(fe FileEntropy) float64 { return fe.Normalized })
+}
+
+// SortByEffectiveAuthors sorts a slice of FileEntropy by EffectiveAuthors in
+// ascending order. Ties are sorted by filename.
+func SortByEffectiveAuthors(files []FileEntropy) {
+	sortByValue(files, func(fe FileEntropy) float64 { return fe.EffectiveAuthors })
+}
+
+// sortByValue sorts files by value in ascending order and by filename, so
+// the order does not depend on the order files came in.
+func sortByValue(files []FileEntropy, value func(FileEntropy) float64) {
+	sort.SliceStable(files, func(i, j int) bool {
+		if vi, vj := value(files[i]), value(files[j]); vi != vj {
+			return vi < vj
+		}
+		return files[i].Filename < files[j].Filename
 	})
 }
+
+// Column names a sortable column of the FileEntropy report.
+type Column int
+
+const (
+	ColumnFilename Column = iota
+	ColumnEntropy
+	ColumnNormalized
+	ColumnEffectiveAuthors
+)
+
+var columnNames = map[Column]string{
+	ColumnFilename:         "filename",
+	ColumnEntropy:          "entropy",
+	ColumnNormalized:       "normalized",
+	ColumnEffectiveAuthors: "effective",
+}
+
+func (c Column) String() string {
+	if name, ok := columnNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("Column(%d)", int(c))
+}
+
+// ParseColumn parses "filename", "entropy", "normalized" or "effective".
+func ParseColumn(s string) (Column, error) {
+	for column, name := range columnNames {
+		if name == s {
+			return column, nil
+		}
+	}
+	return ColumnFilename, fmt.Errorf("unknown column %q, want filename, entropy, normalized or effective", s)
+}
+
+// SortBy sorts files by column in ascending order.
+func SortBy(files []FileEntropy, column Column) {
+	switch column {
+	case ColumnFilename:
+		SortByFilename(files)
+	case ColumnEntropy:
+		SortByEntropy(files)
+	case ColumnNormalized:
+		SortByNormalized(files)
+	case ColumnEffectiveAuthors:
+		SortByEffectiveAuthors(files)
+	}
+}
+
+// EntropyByFile computes the entropy of each file from how much each author
+// contributed to it, sorted by filename.
+//
+//	{
+//	 filename1: {author1: 1, author2: 6},
+//	 filename2: {author0: 5, author1: 3}
+//	}
+func EntropyByFile(counts map[string]map[string]float64) []FileEntropy {
+	files := make([]FileEntropy, 0, len(counts))
+	for filename, authorCounts := range counts {
+		weights := make([]float64, 0, len(authorCounts))
+		for _, count := range authorCounts {
+			weights = append(weights, count)
+		}
+		files = append(files, NewFileEntropy(filename, weights))
+	}
+	SortByFilename(files)
+	return files
+}
+
+// OwnershipEntropy computes the entropy of each file from the number of its
+// current lines each author wrote, sorted by filename.
+func OwnershipEntropy(lineCounts map[string]map[string]int) []FileEntropy {
+	counts := make(map[string]map[string]float64, len(lineCounts))
+	for filename, authorLines := range lineCounts {
+		counts[filename] = make(map[string]float64, len(authorLines))
+		for author, lines := range authorLines {
+			counts[filename][author] = float64(lines)
+		}
+	}
+	return EntropyByFile(counts)
+}
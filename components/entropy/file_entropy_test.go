@@ -2,6 +2,7 @@ package entropy
 
 import (
 	"fmt"
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -31,3 +32,82 @@ func TestFileEntropy(t *testing.T) {
 	// fmt.Println("Sorted by Entropy:", files)
 
 }
+
+func TestEntropyByFile(t *testing.T) {
+	var expected, actual []FileEntropy
+
+	actual = EntropyByFile(map[string]map[string]float64{
+		"b.go": {"alice": 1, "bob": 1},
+		"a.go": {"alice": 3},
+	})
+	expected = []FileEntropy{
+		{Filename: "a.go", Entropy: 0, Authors: 1, Normalized: 0, EffectiveAuthors: 1},
+		{Filename: "b.go", Entropy: 1, Authors: 2, Normalized: 1, EffectiveAuthors: 2},
+	}
+	assert.Equal(t, expected, actual)
+
+	actual = OwnershipEntropy(map[string]map[string]int{
+		"a.go": {"alice": 30, "bob": 10},
+	})
+	h := float64(CalculateEntropyOfCounts([]int{30, 10}))
+	expected = []FileEntropy{
+		{Filename: "a.go", Entropy: h, Authors: 2, Normalized: h, EffectiveAuthors: math.Exp2(h)},
+	}
+	assert.Equal(t, expected, actual)
+
+}
+
+func TestNormalizedEntropy(t *testing.T) {
+	// two balanced authors and eight skewed ones: the raw entropy ranks the
+	// skewed file higher, evenness ranks the balanced one higher
+	files := EntropyByFile(map[string]map[string]float64{
+		"balanced.go": {"a": 5, "b": 5},
+		"skewed.go":   {"a": 30, "b": 1, "c": 1, "d": 1, "e": 1, "f": 1, "g": 1, "h": 1},
+		"solo.go":     {"a": 3, "b": 0},
+	})
+	assert.Equal(t, 1.0, files[0].Normalized)
+	assert.InDelta(t, 2.0, files[0].EffectiveAuthors, 1e-9)
+	assert.Greater(t, files[1].Entropy, files[0].Entropy)
+	assert.Less(t, files[1].Normalized, files[0].Normalized)
+	assert.Equal(t, 8, files[1].Authors)
+	assert.InDelta(t, math.Exp2(files[1].Entropy), files[1].EffectiveAuthors, 1e-9)
+	// an author without weight does not count
+	assert.Equal(t, FileEntropy{Filename: "solo.go", Authors: 1, EffectiveAuthors: 1}, files[2])
+
+	SortBy(files, ColumnNormalized)
+	assert.Equal(t, []string{"solo.go", "skewed.go", "balanced.go"},
+		[]string{files[0].Filename, files[1].Filename, files[2].Filename})
+	SortBy(files, ColumnEntropy)
+	assert.Equal(t, "skewed.go", files[2].Filename)
+	SortBy(files, ColumnEffectiveAuthors)
+	assert.Equal(t, "skewed.go", files[2].Filename)
+}
+
+func TestSortByTies(t *testing.T) {
+	files := []FileEntropy{
+		{Filename: "c.go", Entropy: 1, Normalized: 1, EffectiveAuthors: 2},
+		{Filename: "b.go", Entropy: 0.5, Normalized: 0.5, EffectiveAuthors: 1},
+		{Filename: "a.go", Entropy: 1, Normalized: 1, EffectiveAuthors: 2},
+		{Filename: "d.go", Entropy: 0.5, Normalized: 0.5, EffectiveAuthors: 1},
+	}
+	for _, column := range []Column{ColumnEntropy, ColumnNormalized, ColumnEffectiveAuthors} {
+		SortBy(files, column)
+		var names []string
+		for _, fe := range files {
+			names = append(names, fe.Filename)
+		}
+		assert.Equal(t, []string{"b.go", "d.go", "a.go", "c.go"}, names, column.String())
+		// start over from another order
+		files[0], files[3] = files[3], files[0]
+	}
+}
+
+func TestParseColumn(t *testing.T) {
+	for _, column := range []Column{ColumnFilename, ColumnEntropy, ColumnNormalized, ColumnEffectiveAuthors} {
+		parsed, err := ParseColumn(column.String())
+		assert.NoError(t, err)
+		assert.Equal(t, column, parsed)
+	}
+	_, err := ParseColumn("size")
+	assert.Error(t, err)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 )
 
 // WriteFileEntropies writes a slice of FileEntropy structs to a file in JSON format.
@@ -59,3 +60,43 @@ func PrintFileEntropySlice(entropies []FileEntropy) {
 			entropies[i].Entropy)
 	}
 }
+
+// PrintFileEntropyTable prints the entropy, normalized entropy and effective
+// number of authors of every file.
+func PrintFileEntropyTable(entropies []FileEntropy) {
+	headers := []string{"Filename", "Score", "Normalized", "Effective authors"}
+	rows := make([][]string, len(entropies))
+	for i, fe := range entropies {
+		rows[i] = []string{
+			fe.Filename,
+			fmt.Sprintf("%.4f", fe.Entropy),
+			fmt.Sprintf("%.4f", fe.Normalized),
+			fmt.Sprintf("%.2f", fe.EffectiveAuthors),
+		}
+	}
+
+	// Find the maximum width for each column
+	widths := make([]int, len(headers))
+	for _, row := range append([][]string{headers}, rows...) {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len(cell))
+		}
+	}
+
+	printRow := func(row []string) {
+		line := ""
+		for i, cell := range row {
+			if i > 0 {
+				line += " | "
+			}
+			line += fmt.Sprintf("%-*s", widths[i], cell)
+		}
+		fmt.Println(strings.TrimRight(line, " "))
+	}
+
+	printRow(headers)
+	fmt.Println("---------------------------")
+	for _, row := range rows {
+		printRow(row)
+	}
+}
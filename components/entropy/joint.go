@@ -0,0 +1,96 @@
+package entropy
+
+import "math"
+
+// Decomposition breaks the information in an author×file matrix of
+// contributions down into its parts, all in bits:
+//
+//	H(author, file) = H(author) + H(file|author)
+//	                = H(file) + H(author|file)
+//	I(author; file) = H(author) + H(file) - H(author, file)
+type Decomposition struct {
+	// Author and File are the entropies of the marginal distributions of
+	// contributions over authors and over files.
+	Author float64 `json:"author"`
+	File   float64 `json:"file"`
+	// Joint is the entropy of contributions over (author, file) pairs.
+	Joint float64 `json:"joint"`
+	// AuthorGivenFile is how uncertain the author of a contribution stays
+	// once its file is known: the contribution-weighted mean of the
+	// entropies of the files.
+	AuthorGivenFile float64 `json:"author_given_file"`
+	// FileGivenAuthor is how uncertain the file of a contribution stays
+	// once its author is known, i.e. how widely authors spread their work.
+	FileGivenAuthor float64 `json:"file_given_author"`
+	// MutualInformation is how much knowing the file tells about the author
+	// and vice versa: 0 when everybody works on everything alike, high when
+	// every author keeps to files of their own.
+	MutualInformation float64 `json:"mutual_information"`
+}
+
+// Decompose computes the Decomposition of how much each author contributed
+// to each file. Contributions that are not positive are ignored.
+//
+//	{
+//	 filename1: {author1: 1, author2: 6},
+//	 filename2: {author0: 5, author1: 3}
+//	}
+func Decompose(counts map[string]map[string]float64) Decomposition {
+	authors := make(map[string]float64)
+	var files, pairs []float64
+	for _, authorCounts := range counts {
+		var file float64
+		for author, count := range authorCounts {
+			if count <= 0 {
+				continue
+			}
+			authors[author] += count
+			file += count
+			pairs = append(pairs, count)
+		}
+		files = append(files, file)
+	}
+	authorWeights := make([]float64, 0, len(authors))
+	for _, count := range authors {
+		authorWeights = append(authorWeights, count)
+	}
+
+	d := Decomposition{
+		Author: entropyOf(authorWeights),
+		File:   entropyOf(files),
+		Joint:  entropyOf(pairs),
+	}
+	// the differences can come out a rounding error below zero
+	d.AuthorGivenFile = math.Max(0, d.Joint-d.File)
+	d.FileGivenAuthor = math.Max(0, d.Joint-d.Author)
+	d.MutualInformation = math.Max(0, d.Author+d.File-d.Joint)
+	return d
+}
+
+// JointEntropy is the entropy H(author, file) of contributions over (author,
+// file) pairs.
+func JointEntropy(counts map[string]map[string]float64) float64 {
+	return Decompose(counts).Joint
+}
+
+// entropyOf is the Shannon entropy of weights in float64, which the
+// differences between entropies in Decompose need.
+func entropyOf(weights []float64) float64 {
+	var total float64
+	for _, w := range weights {
+		if w > 0 {
+			total += w
+		}
+	}
+	if total <= 0 {
+		return 0
+	}
+	var h float64
+	for _, w := range weights {
+		if w > 0 {
+			p := w / total
+			h -= p * math.Log2(p)
+		}
+	}
+	return h
+}
@@ -0,0 +1,41 @@
+package entropy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecompose(t *testing.T) {
+	// everybody works on everything alike
+	assert.Equal(t, Decomposition{
+		Author: 1, File: 1, Joint: 2, AuthorGivenFile: 1, FileGivenAuthor: 1,
+	}, Decompose(map[string]map[string]float64{
+		"a.go": {"alice": 1, "bob": 1},
+		"b.go": {"alice": 1, "bob": 1},
+	}))
+
+	// every author keeps to their own file
+	assert.Equal(t, Decomposition{
+		Author: 1, File: 1, Joint: 1, MutualInformation: 1,
+	}, Decompose(map[string]map[string]float64{
+		"a.go": {"alice": 2},
+		"b.go": {"bob": 2, "carol": 0},
+	}))
+
+	counts := map[string]map[string]float64{
+		"filename1": {"author1": 1, "author2": 6},
+		"filename2": {"author0": 5, "author1": 3},
+	}
+	d := Decompose(counts)
+	assert.InDelta(t, d.Joint, d.Author+d.FileGivenAuthor, 1e-12)
+	assert.InDelta(t, d.Joint, d.File+d.AuthorGivenFile, 1e-12)
+	assert.InDelta(t, d.MutualInformation, d.Author-d.AuthorGivenFile, 1e-12)
+	assert.Equal(t, d.Joint, JointEntropy(counts))
+
+	// H(author|file) is the change-weighted mean of the file entropies
+	files := EntropyByFile(counts)
+	assert.InDelta(t, (7*files[0].Entropy+8*files[1].Entropy)/15, d.AuthorGivenFile, 1e-6)
+
+	assert.Equal(t, Decomposition{}, Decompose(nil))
+}
@@ -0,0 +1,209 @@
+package entropy
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"techdebt/components/helpers"
+)
+
+// Measure summarizes how concentrated a distribution is, such as the
+// shares of the authors of a file.
+type Measure interface {
+	// Name identifies the measure in reports and in ParseMeasure.
+	Name() string
+	// Of computes the measure from probabilities that sum to one.
+	Of(ps []helpers.TypeProb) float64
+}
+
+// OfCounts computes m from counts.
+func OfCounts(m Measure, counts []int) float64 {
+	return m.Of(helpers.MakeProbabilitiesFromCounts(counts))
+}
+
+// OfWeights computes m from weighted counts.
+func OfWeights(m Measure, weights []float64) float64 {
+	return m.Of(helpers.MakeProbabilitiesFromWeights(weights))
+}
+
+// Shannon is the Shannon entropy in bits: 0 when one outcome has it all,
+// log2(n) when n outcomes are equally likely.
+type Shannon struct{}
+
+func (Shannon) Name() string { return "shannon" }
+
+func (Shannon) Of(ps []helpers.TypeProb) float64 {
+	return float64(CalculateEntropyOfProbabilities(ps))
+}
+
+// Gini is the Gini coefficient of the shares: 0 when all are equal,
+// (n-1)/n when one of n outcomes has everything.
+type Gini struct{}
+
+func (Gini) Name() string { return "gini" }
+
+func (Gini) Of(ps []helpers.TypeProb) float64 {
+	n := len(ps)
+	if n < 2 {
+		return 0
+	}
+	shares := make([]float64, n)
+	var total float64
+	for i, p := range ps {
+		shares[i] = float64(p)
+		total += shares[i]
+	}
+	if total == 0 {
+		return 0
+	}
+	sort.Float64s(shares)
+
+	var sum float64
+	for i, share := range shares {
+		sum += float64(2*(i+1)-n-1) * share
+	}
+	return sum / (float64(n) * total)
+}
+
+// HHI is the Herfindahl–Hirschman index, the sum of squared shares: 1/n
+// when n outcomes are equally likely, 1 when one has it all.
+type HHI struct{}
+
+func (HHI) Name() string { return "hhi" }
+
+func (HHI) Of(ps []helpers.TypeProb) float64 {
+	var sum float64
+	for _, p := range ps {
+		sum += float64(p) * float64(p)
+	}
+	return sum
+}
+
+// Renyi is the Rényi entropy of the given order in bits. Order 1 is the
+// Shannon entropy, order 0 the log2 of the number of outcomes, order 2 the
+// collision entropy -log2(HHI) and order +Inf the min-entropy. Higher
+// orders weigh the dominant outcomes more.
+type Renyi struct {
+	Order float64
+}
+
+func (r Renyi) Name() string {
+	if math.IsInf(r.Order, 1) {
+		return "renyi-inf"
+	}
+	return "renyi-" + strconv.FormatFloat(r.Order, 'g', -1, 64)
+}
+
+func (r Renyi) Of(ps []helpers.TypeProb) float64 {
+	switch {
+	case r.Order == 1:
+		return Shannon{}.Of(ps)
+	case math.IsInf(r.Order, 1):
+		top := TopShare{}.Of(ps)
+		if top == 0 {
+			return 0
+		}
+		return math.Log2(1 / top)
+	}
+
+	var sum float64
+	for _, p := range ps {
+		if p > 0 {
+			sum += math.Pow(float64(p), r.Order)
+		}
+	}
+	if sum == 0 {
+		return 0
+	}
+	return math.Log2(sum) / (1 - r.Order)
+}
+
+// TopShare is the share of the largest outcome, e.g. of a file's top
+// contributor.
+type TopShare struct{}
+
+func (TopShare) Name() string { return "top-share" }
+
+func (TopShare) Of(ps []helpers.TypeProb) float64 {
+	var top float64
+	for _, p := range ps {
+		top = max(top, float64(p))
+	}
+	return top
+}
+
+// ParseMeasure parses the name of a measure: "shannon", "gini", "hhi",
+// "top-share", or "renyi-<order>" such as "renyi-2" or "renyi-inf". A bare
+// "renyi" has order 2.
+func ParseMeasure(s string) (Measure, error) {
+	switch s {
+	case "shannon":
+		return Shannon{}, nil
+	case "gini":
+		return Gini{}, nil
+	case "hhi":
+		return HHI{}, nil
+	case "top-share":
+		return TopShare{}, nil
+	case "renyi":
+		return Renyi{Order: 2}, nil
+	}
+
+	if order, ok := strings.CutPrefix(s, "renyi-"); ok {
+		alpha, err := strconv.ParseFloat(order, 64)
+		if err == nil && alpha >= 0 && !math.IsNaN(alpha) {
+			return Renyi{Order: alpha}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown measure %q, want shannon, gini, hhi, top-share or renyi-<order>", s)
+}
+
+// ParseMeasures parses a comma-separated list of measure names.
+func ParseMeasures(s string) ([]Measure, error) {
+	var measures []Measure
+	for _, name := range strings.Split(s, ",") {
+		m, err := ParseMeasure(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		measures = append(measures, m)
+	}
+	return measures, nil
+}
+
+// FileMeasures holds the values of several measures for one file, in the
+// order the measures were given.
+type FileMeasures struct {
+	Filename string
+	Values   []float64
+}
+
+// MeasuresByFile computes every measure for each file from how much each
+// author contributed to it, sorted by filename.
+func MeasuresByFile(counts map[string]map[string]float64, measures []Measure) []FileMeasures {
+	files := make([]FileMeasures, 0, len(counts))
+	for filename, authorCounts := range counts {
+		weights := make([]float64, 0, len(authorCounts))
+		for _, count := range authorCounts {
+			weights = append(weights, count)
+		}
+		files = append(files, FileMeasures{Filename: filename, Values: MeasureAll(measures, weights)})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Filename < files[j].Filename
+	})
+	return files
+}
+
+// MeasureAll computes every measure from the same weighted counts.
+func MeasureAll(measures []Measure, weights []float64) []float64 {
+	ps := helpers.MakeProbabilitiesFromWeights(weights)
+	values := make([]float64, len(measures))
+	for i, m := range measures {
+		values[i] = m.Of(ps)
+	}
+	return values
+}
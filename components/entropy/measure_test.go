@@ -0,0 +1,64 @@
+package entropy
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeasures(t *testing.T) {
+	even := []int{5, 5, 5, 5}
+	skewed := []int{7, 1, 0, 0}
+
+	cases := []struct {
+		measure      Measure
+		even, skewed float64
+	}{
+		{Shannon{}, 2, 0.5435644},
+		{Gini{}, 0, 0.6875},
+		{HHI{}, 0.25, 0.78125},
+		{TopShare{}, 0.25, 0.875},
+		{Renyi{Order: 0}, 2, 1},
+		{Renyi{Order: 1}, 2, 0.5435644},
+		{Renyi{Order: 2}, 2, -math.Log2(0.78125)},
+		{Renyi{Order: math.Inf(1)}, 2, -math.Log2(0.875)},
+	}
+	for _, c := range cases {
+		assert.InDelta(t, c.even, OfCounts(c.measure, even), 1e-6, c.measure.Name())
+		assert.InDelta(t, c.skewed, OfCounts(c.measure, skewed), 1e-6, c.measure.Name())
+	}
+
+	// weights and counts describe the same distribution
+	assert.InDelta(t, OfCounts(Gini{}, skewed), OfWeights(Gini{}, []float64{3.5, 0.5, 0, 0}), 1e-6)
+	for _, m := range []Measure{Shannon{}, Gini{}, HHI{}, TopShare{}, Renyi{Order: 2}} {
+		assert.Equal(t, 0.0, OfWeights(m, nil), m.Name())
+	}
+}
+
+func TestParseMeasures(t *testing.T) {
+	measures, err := ParseMeasures("shannon, gini,hhi,top-share,renyi,renyi-0.5,renyi-inf")
+	require.NoError(t, err)
+	var names []string
+	for _, m := range measures {
+		names = append(names, m.Name())
+	}
+	assert.Equal(t, []string{"shannon", "gini", "hhi", "top-share", "renyi-2", "renyi-0.5", "renyi-inf"}, names)
+
+	for _, bad := range []string{"entropy", "renyi-", "renyi--1", ""} {
+		_, err := ParseMeasures(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestMeasuresByFile(t *testing.T) {
+	files := MeasuresByFile(map[string]map[string]float64{
+		"b.go": {"alice": 1, "bob": 1},
+		"a.go": {"alice": 3},
+	}, []Measure{Shannon{}, TopShare{}})
+	assert.Equal(t, []FileMeasures{
+		{Filename: "a.go", Values: []float64{0, 1}},
+		{Filename: "b.go", Values: []float64{1, 0.5}},
+	}, files)
+}
@@ -0,0 +1,201 @@
+package entropy
+
+import (
+	"path"
+	"sort"
+
+	"techdebt/components/commitinfo"
+)
+
+// DirectoryNode is a directory in a roll-up of the history by directory.
+// Its figures cover every file anywhere below it.
+type DirectoryNode struct {
+	// Path is the slash-separated path of the directory, "." at the root.
+	Path string `json:"path"`
+	// Entropy is the entropy of the author weights pooled over all files
+	// of the subtree.
+	Entropy float64 `json:"entropy"`
+	Authors int     `json:"authors"`
+	Files   int     `json:"files"`
+	// Commits is the number of commits that changed the subtree.
+	Commits int `json:"commits"`
+	// Lowest lists the direct children, files and directories alike, with
+	// the lowest entropy, lowest first.
+	Lowest []ChildEntropy `json:"lowest,omitempty"`
+	// Children are the subdirectories, sorted by path.
+	Children []*DirectoryNode `json:"children,omitempty"`
+	// Collapsed counts the directories Truncate removed below this one, at
+	// any depth.
+	Collapsed int `json:"collapsed,omitempty"`
+}
+
+// ChildEntropy is the entropy of a file or directory below a DirectoryNode.
+type ChildEntropy struct {
+	Name    string  `json:"name"`
+	Entropy float64 `json:"entropy"`
+	Dir     bool    `json:"dir,omitempty"`
+}
+
+// TreeBuilder rolls up commit records into a DirectoryNode tree, one record
+// at a time.
+type TreeBuilder struct {
+	// counts holds the author weights of every file
+	counts map[string]map[string]float64
+	// commits holds the commit hashes that changed every directory
+	commits map[string]map[string]bool
+	// unhashed counts the records of every directory without a Hash, each
+	// of which counts as a commit of its own
+	unhashed map[string]int
+}
+
+func NewTreeBuilder() *TreeBuilder {
+	return &TreeBuilder{
+		counts:   make(map[string]map[string]float64),
+		commits:  make(map[string]map[string]bool),
+		unhashed: make(map[string]int),
+	}
+}
+
+// Add counts one commit record.
+func (b *TreeBuilder) Add(commit commitinfo.CommitInfo) {
+	if b.counts[commit.Filename] == nil {
+		b.counts[commit.Filename] = make(map[string]float64)
+	}
+	b.counts[commit.Filename][commit.Author] += commit.Weight
+
+	for _, dir := range ancestors(commit.Filename) {
+		if commit.Hash == "" {
+			b.unhashed[dir]++
+			continue
+		}
+		if b.commits[dir] == nil {
+			b.commits[dir] = make(map[string]bool)
+		}
+		b.commits[dir][commit.Hash] = true
+	}
+}
+
+// Build returns the root of the tree, with up to lowest children listed in
+// the Lowest of every node.
+func (b *TreeBuilder) Build(lowest int) *DirectoryNode {
+	nodes := map[string]*DirectoryNode{".": {Path: "."}}
+	pooled := map[string]map[string]float64{".": {}}
+	// children collects the entropies of the files and subdirectories
+	// directly in every directory
+	children := make(map[string][]ChildEntropy)
+
+	node := func(dir string) *DirectoryNode {
+		if n, ok := nodes[dir]; ok {
+			return n
+		}
+		n := &DirectoryNode{Path: dir}
+		nodes[dir] = n
+		pooled[dir] = make(map[string]float64)
+		return n
+	}
+
+	for filename, authors := range b.counts {
+		weights := make([]float64, 0, len(authors))
+		for _, weight := range authors {
+			weights = append(weights, weight)
+		}
+		dir := path.Dir(filename)
+		children[dir] = append(children[dir], ChildEntropy{
+			Name:    path.Base(filename),
+			Entropy: float64(CalculateEntropyOfWeights(weights)),
+		})
+
+		for _, dir := range ancestors(filename) {
+			node(dir).Files++
+			for author, weight := range authors {
+				pooled[dir][author] += weight
+			}
+		}
+	}
+
+	for dir, n := range nodes {
+		weights := make([]float64, 0, len(pooled[dir]))
+		for _, weight := range pooled[dir] {
+			weights = append(weights, weight)
+		}
+		n.Entropy = float64(CalculateEntropyOfWeights(weights))
+		n.Authors = len(pooled[dir])
+		n.Commits = len(b.commits[dir]) + b.unhashed[dir]
+		if dir != "." {
+			parent := nodes[path.Dir(dir)]
+			parent.Children = append(parent.Children, n)
+		}
+	}
+
+	for dir, n := range nodes {
+		sort.Slice(n.Children, func(i, j int) bool {
+			return n.Children[i].Path < n.Children[j].Path
+		})
+		for _, child := range n.Children {
+			children[dir] = append(children[dir], ChildEntropy{Name: path.Base(child.Path), Entropy: child.Entropy, Dir: true})
+		}
+		n.Lowest = lowestChildren(children[dir], lowest)
+	}
+	return nodes["."]
+}
+
+// lowestChildren returns up to n of children with the lowest entropy, ties
+// broken by name. A negative n lists none.
+func lowestChildren(children []ChildEntropy, n int) []ChildEntropy {
+	n = max(n, 0)
+	sort.Slice(children, func(i, j int) bool {
+		if children[i].Entropy != children[j].Entropy {
+			return children[i].Entropy < children[j].Entropy
+		}
+		return children[i].Name < children[j].Name
+	})
+	if len(children) > n {
+		children = children[:n]
+	}
+	if len(children) == 0 {
+		return nil
+	}
+	return children
+}
+
+// ancestors returns the directories containing the file at filename,
+// innermost first and "." last.
+func ancestors(filename string) []string {
+	var dirs []string
+	for dir := path.Dir(filename); ; dir = path.Dir(dir) {
+		dirs = append(dirs, dir)
+		if dir == "." || dir == "/" {
+			return dirs
+		}
+	}
+}
+
+// Truncate returns a copy of the tree without the directories more than
+// depth levels below n, counting them in the Collapsed of the deepest
+// directories kept. A negative depth keeps the whole tree.
+func (n *DirectoryNode) Truncate(depth int) *DirectoryNode {
+	truncated := *n
+	if depth == 0 {
+		truncated.Collapsed = n.subdirectories()
+		truncated.Children = nil
+		return &truncated
+	}
+	truncated.Children = make([]*DirectoryNode, len(n.Children))
+	for i, child := range n.Children {
+		truncated.Children[i] = child.Truncate(depth - 1)
+	}
+	if len(truncated.Children) == 0 {
+		truncated.Children = nil
+	}
+	return &truncated
+}
+
+// subdirectories counts the directories below n, including those an
+// earlier Truncate collapsed.
+func (n *DirectoryNode) subdirectories() int {
+	count := n.Collapsed
+	for _, child := range n.Children {
+		count += 1 + child.subdirectories()
+	}
+	return count
+}
@@ -0,0 +1,78 @@
+package entropy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"techdebt/components/commitinfo"
+)
+
+func TestTreeBuilder(t *testing.T) {
+	b := NewTreeBuilder()
+	for _, commit := range []commitinfo.CommitInfo{
+		{Hash: "c1", Author: "alice", Filename: "main.go", Weight: 1},
+		{Hash: "c1", Author: "alice", Filename: "core/a.go", Weight: 1},
+		{Hash: "c2", Author: "bob", Filename: "core/a.go", Weight: 1},
+		{Hash: "c3", Author: "alice", Filename: "core/util/b.go", Weight: 2},
+		{Author: "bob", Filename: "web/c.go", Weight: 1},
+	} {
+		b.Add(commit)
+	}
+
+	root := b.Build(2)
+	assert.Equal(t, ".", root.Path)
+	assert.Equal(t, 4, root.Files)
+	assert.Equal(t, 2, root.Authors)
+	// c1, c2, c3 and the record without a hash
+	assert.Equal(t, 4, root.Commits)
+	// alice 4, bob 2
+	assert.InDelta(t, 0.9183, root.Entropy, 1e-4)
+	assert.Equal(t, []ChildEntropy{
+		{Name: "main.go", Entropy: 0},
+		{Name: "web", Entropy: 0, Dir: true},
+	}, root.Lowest)
+
+	require.Len(t, root.Children, 2)
+	core := root.Children[0]
+	assert.Equal(t, "core", core.Path)
+	assert.Equal(t, 2, core.Files)
+	assert.Equal(t, 3, core.Commits)
+	// alice 3, bob 1
+	assert.InDelta(t, 0.8113, core.Entropy, 1e-4)
+	assert.Equal(t, []ChildEntropy{
+		{Name: "util", Entropy: 0, Dir: true},
+		{Name: "a.go", Entropy: 1},
+	}, core.Lowest)
+	require.Len(t, core.Children, 1)
+	assert.Equal(t, "core/util", core.Children[0].Path)
+	assert.Equal(t, "web", root.Children[1].Path)
+	assert.Equal(t, 1, root.Children[1].Commits)
+}
+
+func TestTruncate(t *testing.T) {
+	b := NewTreeBuilder()
+	for _, filename := range []string{"a/b/c/d.go", "a/e.go", "f/g.go"} {
+		b.Add(commitinfo.CommitInfo{Author: "alice", Filename: filename, Weight: 1})
+	}
+	root := b.Build(0)
+	assert.Nil(t, root.Lowest)
+	assert.Nil(t, b.Build(-1).Lowest)
+
+	truncated := root.Truncate(1)
+	require.Len(t, truncated.Children, 2)
+	assert.Nil(t, truncated.Children[0].Children)
+	// a/b and a/b/c
+	assert.Equal(t, 2, truncated.Children[0].Collapsed)
+	assert.Equal(t, 0, truncated.Children[1].Collapsed)
+	// the original keeps its subtrees
+	assert.Len(t, root.Children[0].Children, 1)
+
+	// a, a/b, a/b/c and f
+	assert.Equal(t, 4, root.Truncate(0).Collapsed)
+	// truncating again keeps the count
+	assert.Equal(t, 4, truncated.Truncate(0).Collapsed)
+	assert.Equal(t, 2, root.Truncate(1).Children[0].Truncate(0).Collapsed)
+	assert.Equal(t, root, root.Truncate(-1))
+}
@@ -0,0 +1,98 @@
+package git
+
+import (
+	"context"
+	"fmt"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// LineOwnership counts, per file, how many of its current lines each
+// author wrote.
+//
+//	{
+//	 filename1: {author1: 120, author2: 4},
+//	 filename2: {author0: 37}
+//	}
+type LineOwnership map[string]map[string]int
+
+// Ownership blames every text file at opts.Ref (HEAD by default) and
+// attributes each surviving line to the author who last changed it. Authors
+// are resolved like in History, lines by authors opts.Filter excludes are
+// skipped, and opts.Paths and the path filters of opts limit the files
+// blamed. Options about walking history do not apply.
+func Ownership(ctx context.Context, opts HistoryOptions) (LineOwnership, error) {
+	// blame always reads the repository with go-git
+	opts.Cache = false
+	source, err := newGoGitSource(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	commit, err := source.repo.CommitObject(source.start)
+	if err != nil {
+		return nil, fmt.Errorf("could not read commit %s: %w", source.start, err)
+	}
+
+	identities, err := newIdentityResolver(ctx, source, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := newPathFilter(ctx, source, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := commit.Files()
+	if err != nil {
+		return nil, fmt.Errorf("could not list files: %w", err)
+	}
+	defer files.Close()
+
+	ownership := make(LineOwnership)
+	err = files.ForEach(func(f *object.File) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !matchesPaths(f.Name, opts.Paths) {
+			return nil
+		}
+		keep, err := paths.keeps(ctx, f.Name)
+		if err != nil || !keep {
+			return err
+		}
+
+		binary, err := f.IsBinary()
+		if err != nil {
+			return fmt.Errorf("could not read %s: %w", f.Name, err)
+		}
+		if binary {
+			return nil
+		}
+
+		result, err := git.Blame(commit, f.Name)
+		if err != nil {
+			return fmt.Errorf("could not blame %s: %w", f.Name, err)
+		}
+
+		authors := make(map[string]int)
+		for _, line := range result.Lines {
+			id := Identity{Name: line.AuthorName, Email: line.Author}
+			if opts.Filter.excludesAuthor(id) {
+				continue
+			}
+			authors[identities.resolve(id).Name]++
+		}
+		if len(authors) > 0 {
+			ownership[f.Name] = authors
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ownership, nil
+}
@@ -0,0 +1,30 @@
+package git
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOwnership(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	r.commit("alice", map[string]string{"a.txt": "1\n2\n3\n", "b.txt": "b\n"})
+	r.commit("bob", map[string]string{"a.txt": "1\ntwo\n3\n4\n5\n"})
+	r.commitAs("dependabot[bot]", "bot@example.com", "bump", map[string]string{"b.txt": "b\nbump\n", "bin.dat": "\x00\x01"})
+
+	ownership, err := Ownership(ctx, HistoryOptions{RepoPath: r.path})
+	require.NoError(t, err)
+	assert.Equal(t, LineOwnership{
+		"a.txt": {"alice": 2, "bob": 3},
+		"b.txt": {"alice": 1, "dependabot[bot]": 1},
+	}, ownership)
+
+	filter := NewCommitFilter()
+	filter.ExcludeBots()
+	ownership, err = Ownership(ctx, HistoryOptions{RepoPath: r.path, Filter: filter, Paths: []string{"b.txt"}})
+	require.NoError(t, err)
+	assert.Equal(t, LineOwnership{"b.txt": {"alice": 1}}, ownership)
+}
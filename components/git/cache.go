@@ -0,0 +1,144 @@
+package git
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// cacheVersion is bumped whenever the meaning of cached changes changes, so
+// that caches written by older builds are discarded.
+const cacheVersion = 3
+
+// changeCache stores the files changed by each commit, keyed by commit hash.
+// It lives in the repository's git directory under techdebt/, so diffs
+// only have to be computed for commits that landed since the last run.
+type changeCache struct {
+	path string
+	// mu guards Commits and dirty, which diff workers update concurrently
+	mu sync.Mutex
+	// dirty is set once an entry has been added
+	dirty bool
+
+	Version int `json:"version"`
+	// Diff describes the diff options the changes were computed with.
+	Diff    string                  `json:"diff"`
+	Commits map[string][]FileChange `json:"commits"`
+}
+
+// openCache loads the cache of repo for changes computed with the options
+// described by key. A missing, unreadable or incompatible cache file yields
+// an empty cache.
+func openCache(repo *git.Repository, key string) (*changeCache, error) {
+	storage, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return nil, errors.New("cache needs a repository stored on disk")
+	}
+
+	empty := &changeCache{
+		path:    filepath.Join(storage.Filesystem().Root(), "techdebt", "changes.json"),
+		Version: cacheVersion,
+		Diff:    key,
+		Commits: make(map[string][]FileChange),
+	}
+
+	data, err := os.ReadFile(empty.path)
+	if err != nil {
+		return empty, nil
+	}
+
+	var cache changeCache
+	if err := json.Unmarshal(data, &cache); err != nil ||
+		cache.Version != empty.Version || cache.Diff != empty.Diff || cache.Commits == nil {
+		return empty, nil
+	}
+	cache.path = empty.path
+	return &cache, nil
+}
+
+// get returns the cached changes of a commit.
+func (c *changeCache) get(hash string) ([]FileChange, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	changes, ok := c.Commits[hash]
+	return changes, ok
+}
+
+// put stores the changes of a commit.
+func (c *changeCache) put(hash string, changes []FileChange) {
+	if changes == nil {
+		changes = []FileChange{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Commits[hash] = changes
+	c.dirty = true
+}
+
+// save drops commits that are no longer reachable from any ref, e.g. after
+// a force-push, and writes the cache back to disk.
+func (c *changeCache) save(repo *git.Repository) error {
+	reachable, err := reachableCommits(repo)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for hash := range c.Commits {
+		if !reachable[hash] {
+			delete(c.Commits, hash)
+			c.dirty = true
+		}
+	}
+	if !c.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("could not encode cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("could not create cache directory: %w", err)
+	}
+
+	// write to a temporary file first so an interrupted run cannot leave a
+	// truncated cache behind
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("could not write cache: %w", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("could not write cache: %w", err)
+	}
+	c.dirty = false
+	return nil
+}
+
+// reachableCommits returns the hashes of all commits reachable from HEAD or
+// any ref.
+func reachableCommits(repo *git.Repository) (map[string]bool, error) {
+	commitIter, err := repo.Log(&git.LogOptions{All: true})
+	if err != nil {
+		return nil, fmt.Errorf("could not list reachable commits: %w", err)
+	}
+	defer commitIter.Close()
+
+	reachable := make(map[string]bool)
+	err = commitIter.ForEach(func(c *object.Commit) error {
+		reachable[c.Hash.String()] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not list reachable commits: %w", err)
+	}
+	return reachable, nil
+}
@@ -0,0 +1,83 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readCache(t *testing.T, repoPath string) *changeCache {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(repoPath, ".git", "techdebt", "changes.json"))
+	require.NoError(t, err)
+	var cache changeCache
+	require.NoError(t, json.Unmarshal(data, &cache))
+	return &cache
+}
+
+func writeCache(t *testing.T, repoPath string, cache *changeCache) {
+	t.Helper()
+	data, err := json.Marshal(cache)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, ".git", "techdebt", "changes.json"), data, 0o644))
+}
+
+func TestHistoryCache(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	first := r.commit("alice", map[string]string{"a.txt": "a\n"})
+	second := r.commit("bob", map[string]string{"b.txt": "b\n"})
+	opts := HistoryOptions{RepoPath: r.path, Cache: true}
+
+	commits, err := History(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice:a.txt", "bob:b.txt"}, filesByAuthor(commits))
+
+	cache := readCache(t, r.path)
+	assert.Equal(t, []FileChange{{Path: "a.txt", Kind: ChangeAdded}}, cache.Commits[first.String()])
+	assert.Equal(t, []FileChange{{Path: "b.txt", Kind: ChangeAdded}}, cache.Commits[second.String()])
+
+	// cached commits are not diffed again, which a doctored entry reveals
+	cache.Commits[first.String()] = []FileChange{{Path: "cached.txt"}}
+	writeCache(t, r.path, cache)
+	r.commit("carol", map[string]string{"c.txt": "c\n"})
+
+	commits, err = History(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice:cached.txt", "bob:b.txt", "carol:c.txt"}, filesByAuthor(commits))
+	assert.Len(t, readCache(t, r.path).Commits, 3)
+
+	// a cache built with other diff options is discarded
+	commits, err = History(ctx, HistoryOptions{RepoPath: r.path, Cache: true, DetectRenames: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice:a.txt", "bob:b.txt", "carol:c.txt"}, filesByAuthor(commits))
+	assert.Equal(t, "renames:60", readCache(t, r.path).Diff)
+}
+
+func TestHistoryCacheDropsRewrittenCommits(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	first := r.commit("alice", map[string]string{"a.txt": "a\n"})
+	second := r.commit("bob", map[string]string{"b.txt": "b\n"})
+	opts := HistoryOptions{RepoPath: r.path, Cache: true}
+
+	_, err := History(ctx, opts)
+	require.NoError(t, err)
+
+	// rewind master as a force-push would
+	require.NoError(t, r.repo.Storer.SetReference(plumbing.NewHashReference("refs/heads/master", first)))
+
+	commits, err := History(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice:a.txt"}, filesByAuthor(commits))
+
+	cache := readCache(t, r.path)
+	assert.Contains(t, cache.Commits, first.String())
+	assert.NotContains(t, cache.Commits, second.String())
+}
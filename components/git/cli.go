@@ -0,0 +1,593 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cliFormat is the commit header of the git log output parsed by cliSource.
+// Its fields are separated by NUL, the one byte no name or message can
+// contain, and -z ends the header and every numstat entry with NUL as well.
+const (
+	cliFormat       = "--format=%H%x00%P%x00%an%x00%ae%x00%aI%x00%B"
+	cliHeaderFields = 6
+)
+
+// cliSource reads history by running the local git binary, which diffs
+// large histories much faster than go-git.
+type cliSource struct {
+	dir   string
+	start string
+	opts  HistoryOptions
+
+	// batch reads the blobs of ReadHeader, started on first use
+	mu    sync.Mutex
+	batch *catFile
+}
+
+func newCLISource(ctx context.Context, opts HistoryOptions) (*cliSource, error) {
+	if _, err := os.Stat(opts.RepoPath); os.IsNotExist(err) && opts.CloneURL != "" {
+		cmd := exec.CommandContext(ctx, "git", "clone", "--quiet", "--", opts.CloneURL, opts.RepoPath)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v: %s", ErrCloneFailed, opts.CloneURL, err, bytes.TrimSpace(out))
+		}
+	}
+
+	s := &cliSource{dir: opts.RepoPath, opts: opts}
+	if err := s.checkRepository(ctx); err != nil {
+		return nil, err
+	}
+
+	start, err := s.resolveStart(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.start = start
+	if !opts.AsOf.IsZero() {
+		if s.start, err = s.commitAsOf(ctx, opts.AsOf); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// git runs a git command in the repository and returns its output.
+func (s *cliSource) git(ctx context.Context, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", s.dir}, args...)...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w: %s", args[0], err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return out, nil
+}
+
+// checkRepository makes sure dir is the top of a work tree or a bare
+// repository, as go-git requires, not just somewhere inside one.
+func (s *cliSource) checkRepository(ctx context.Context) error {
+	dir, err := filepath.Abs(s.dir)
+	if err == nil {
+		dir, err = filepath.EvalSymlinks(dir)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrNotRepository, s.dir)
+	}
+
+	out, err := s.git(ctx, "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		if _, lookErr := exec.LookPath("git"); lookErr != nil {
+			return fmt.Errorf("git backend needs the git binary: %w", lookErr)
+		}
+		return fmt.Errorf("%w: %s", ErrNotRepository, s.dir)
+	}
+	gitDir, err := filepath.EvalSymlinks(strings.TrimSpace(string(out)))
+	if err != nil || (gitDir != dir && gitDir != filepath.Join(dir, ".git")) {
+		return fmt.Errorf("%w: %s", ErrNotRepository, s.dir)
+	}
+	return nil
+}
+
+// resolveStart turns the ref of the options into the commit hash the walk
+// starts from.
+func (s *cliSource) resolveStart(ctx context.Context) (string, error) {
+	ref := s.opts.Ref
+	if ref == "" {
+		out, err := s.git(ctx, "rev-parse", "--verify", "--quiet", "HEAD^{commit}")
+		if err != nil {
+			return "", ErrEmptyRepository
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+
+	if strings.HasPrefix(ref, "-") {
+		return "", fmt.Errorf("%w: %s", ErrUnknownRef, ref)
+	}
+	out, err := s.git(ctx, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrUnknownRef, ref)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// commitAsOf returns the newest commit reachable from the start that was
+// committed at or before asOf.
+func (s *cliSource) commitAsOf(ctx context.Context, asOf time.Time) (string, error) {
+	out, err := s.git(ctx, "rev-list", "-1", "--date-order",
+		"--min-age="+strconv.FormatInt(asOf.Unix(), 10), s.start, "--")
+	if err != nil {
+		return "", err
+	}
+	hash := strings.TrimSpace(string(out))
+	if hash == "" {
+		return "", fmt.Errorf("%w as of %s", ErrEmptyRepository, asOf.Format(time.RFC3339))
+	}
+	return hash, nil
+}
+
+func (s *cliSource) ReadFile(ctx context.Context, name string) ([]byte, error) {
+	out, err := s.git(ctx, "ls-tree", "-z", s.start, "--", name)
+	if err != nil {
+		return nil, err
+	}
+	// entries look like "<mode> <type> <hash>\t<name>"
+	entry, path, _ := strings.Cut(strings.TrimSuffix(string(out), "\x00"), "\t")
+	fields := strings.Fields(entry)
+	if path != name || len(fields) != 3 || fields[1] != "blob" {
+		return nil, nil
+	}
+	return s.git(ctx, "cat-file", "blob", fields[2])
+}
+
+func (s *cliSource) ReadHeader(ctx context.Context, name string, size int) ([]byte, error) {
+	// git cat-file --batch reads one object name per line
+	if strings.Contains(name, "\n") {
+		content, err := s.ReadFile(ctx, name)
+		return content[:min(size, len(content))], err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.batch == nil {
+		batch, err := startCatFile(s.dir)
+		if err != nil {
+			return nil, err
+		}
+		s.batch = batch
+	}
+
+	content, err := s.batch.read(s.start+":"+name, size)
+	if err != nil {
+		// the output may be out of step now, start over next time
+		s.batch.close()
+		s.batch = nil
+		return nil, fmt.Errorf("could not read %s: %w", name, err)
+	}
+	return content, nil
+}
+
+func (s *cliSource) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.batch == nil {
+		return nil
+	}
+	err := s.batch.close()
+	s.batch = nil
+	return err
+}
+
+// catFile is a running git cat-file --batch, which reads any number of
+// objects without starting a git process for each.
+type catFile struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+}
+
+func startCatFile(dir string) (*catFile, error) {
+	cmd := exec.Command("git", "-C", dir, "cat-file", "--batch")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("could not run git cat-file: %w", err)
+	}
+	return &catFile{cmd: cmd, stdin: stdin, stdout: bufio.NewReader(stdout)}, nil
+}
+
+// read returns at most the first size bytes of the blob object names, or
+// nil if it is missing or no blob.
+func (c *catFile) read(object string, size int) ([]byte, error) {
+	if _, err := io.WriteString(c.stdin, object+"\n"); err != nil {
+		return nil, err
+	}
+	header, err := c.stdout.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(header, " missing\n") {
+		return nil, nil
+	}
+
+	// found objects are described as "<hash> <type> <size>"
+	fields := strings.Fields(header)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("unexpected git cat-file output %q", header)
+	}
+	length, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("unexpected git cat-file output %q", header)
+	}
+
+	content := make([]byte, min(size, length))
+	if _, err := io.ReadFull(c.stdout, content); err != nil {
+		return nil, err
+	}
+	// skip the rest of the object and the newline after it
+	if _, err := c.stdout.Discard(length - len(content) + 1); err != nil {
+		return nil, err
+	}
+	if fields[1] != "blob" {
+		return nil, nil
+	}
+	return content, nil
+}
+
+func (c *catFile) close() error {
+	c.stdin.Close()
+	return c.cmd.Wait()
+}
+
+// logArgs builds the git log command line for the options.
+func (s *cliSource) logArgs() []string {
+	args := []string{
+		"-C", s.dir,
+		"-c", "log.showSignature=false",
+		"-c", "log.diffMerges=separate",
+		"log", "-z", "--raw", "--numstat", "--root", "--date-order",
+		"--no-color", "--no-ext-diff", cliFormat,
+	}
+
+	// -m diffs merges against each parent, for combineParentChanges
+	args = append(args, "-m")
+
+	if s.opts.DetectRenames {
+		score := s.opts.RenameScore
+		if score == 0 {
+			score = DefaultRenameScore
+		}
+		args = append(args, fmt.Sprintf("-M%d%%", score))
+	} else {
+		args = append(args, "--no-renames")
+	}
+
+	return append(args, s.revisionArgs()...)
+}
+
+// revisionArgs selects the commits of the options for git log.
+func (s *cliSource) revisionArgs() []string {
+	var args []string
+	if s.opts.Merges == MergesFirstParent {
+		args = append(args, "--first-parent")
+	}
+
+	// git compares whole seconds, both bounds inclusive
+	if !s.opts.Since.IsZero() {
+		since := s.opts.Since.Unix()
+		if s.opts.Since.Nanosecond() > 0 {
+			since++
+		}
+		args = append(args, "--max-age="+strconv.FormatInt(since, 10))
+	}
+	if !s.opts.Until.IsZero() {
+		args = append(args, "--min-age="+strconv.FormatInt(s.opts.Until.Unix(), 10))
+	}
+
+	return append(args, s.start, "--")
+}
+
+func (s *cliSource) Walk(ctx context.Context, visit func(Commit, ChangesFunc) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", s.logArgs()...)
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("could not run git log: %w", err)
+	}
+
+	walkErr := s.parseLog(bufio.NewReaderSize(stdout, 1<<16), visit)
+	if walkErr != nil {
+		// stop git early, its remaining output is not needed
+		cancel()
+	}
+	waitErr := cmd.Wait()
+
+	if walkErr != nil {
+		return walkErr
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if waitErr != nil {
+		return fmt.Errorf("git log: %w: %s", waitErr, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return nil
+}
+
+func (s *cliSource) Authors(ctx context.Context) ([]Identity, error) {
+	args := []string{"log", "-z", "--no-show-signature", "--date-order", "--no-color", "--format=%an%x00%ae"}
+	out, err := s.git(ctx, append(args, s.revisionArgs()...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	// -z ends every commit's "name NUL email" with another NUL
+	fields := strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00")
+	if len(fields) == 1 && fields[0] == "" {
+		return nil, nil
+	}
+	if len(fields)%2 != 0 {
+		return nil, errors.New("could not parse git log authors")
+	}
+	authors := make([]Identity, 0, len(fields)/2)
+	for i := 0; i < len(fields); i += 2 {
+		authors = append(authors, Identity{Name: fields[i], Email: fields[i+1]})
+	}
+	return authors, nil
+}
+
+// cliCommit is a commit parsed from git log output, with one list of
+// changes per parent it was diffed against.
+type cliCommit struct {
+	commit  Commit
+	changes [][]FileChange
+}
+
+// parseLog reads git log output and calls visit once per commit. Merges
+// appear once per parent in the output and are combined first.
+func (s *cliSource) parseLog(r *bufio.Reader, visit func(Commit, ChangesFunc) error) error {
+	var pending *cliCommit
+
+	flush := func() error {
+		if pending == nil {
+			return nil
+		}
+		files := combineParentChanges(pending.changes)
+		// as for go-git, merges diffed against every parent have no line
+		// stats: those against the first parent include the other side
+		if !s.opts.LineStats || len(pending.changes) > 1 {
+			for i := range files {
+				files[i].Added, files[i].Deleted = 0, 0
+			}
+		}
+		c := pending.commit
+		pending = nil
+		return visit(c, func() ([]FileChange, error) { return files, nil })
+	}
+
+	tokens := &logTokens{r: r}
+	for {
+		commit, changes, err := parseLogRecord(tokens)
+		if err == io.EOF {
+			return flush()
+		}
+		if err != nil {
+			return err
+		}
+
+		if pending != nil && pending.commit.Hash != commit.Hash {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		if pending == nil {
+			pending = &cliCommit{commit: commit}
+		}
+		pending.changes = append(pending.changes, changes)
+	}
+}
+
+// logTokens splits git log -z output into its NUL terminated tokens.
+type logTokens struct {
+	r      *bufio.Reader
+	peeked *string
+}
+
+// next returns the next token without its NUL terminator, or io.EOF.
+func (t *logTokens) next() (string, error) {
+	if t.peeked != nil {
+		token := *t.peeked
+		t.peeked = nil
+		return token, nil
+	}
+
+	token, err := t.r.ReadString(0)
+	if err == io.EOF && token == "" {
+		return "", io.EOF
+	}
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimSuffix(token, "\x00"), nil
+}
+
+// peek returns the next token without consuming it.
+func (t *logTokens) peek() (string, error) {
+	token, err := t.next()
+	if err != nil {
+		return "", err
+	}
+	t.peeked = &token
+	return token, nil
+}
+
+// combineParentChanges keeps the changes against the first parent whose
+// path was changed against every parent, like changedFiles does for go-git.
+func combineParentChanges(changes [][]FileChange) []FileChange {
+	if len(changes) == 1 {
+		return changes[0]
+	}
+
+	seen := make(map[string]int)
+	for i, parentChanges := range changes {
+		for _, change := range parentChanges {
+			if seen[change.Path] == i {
+				seen[change.Path]++
+			}
+		}
+	}
+
+	var files []FileChange
+	for _, change := range changes[0] {
+		if seen[change.Path] == len(changes) {
+			files = append(files, change)
+		}
+	}
+	return files
+}
+
+// parseLogRecord parses one commit header and its -z --numstat output. It
+// returns io.EOF when the output has no more commits.
+func parseLogRecord(tokens *logTokens) (Commit, []FileChange, error) {
+	fields := make([]string, cliHeaderFields)
+	for i := range fields {
+		field, err := tokens.next()
+		if err == io.EOF && i > 0 {
+			err = errors.New("could not parse git log output")
+		}
+		if err != nil {
+			return Commit{}, nil, err
+		}
+		fields[i] = field
+	}
+	// the numstat entries of the previous commit end with a newline
+	fields[0] = strings.TrimLeft(fields[0], "\n")
+
+	when, err := time.Parse(time.RFC3339, fields[4])
+	if err != nil {
+		return Commit{}, nil, fmt.Errorf("could not parse date of commit %s: %w", fields[0], err)
+	}
+
+	commit := Commit{
+		Hash:    fields[0],
+		Author:  Identity{Name: fields[2], Email: fields[3]},
+		When:    when,
+		Message: fields[5],
+		Parents: len(strings.Fields(fields[1])),
+	}
+
+	changes, err := parseNumstat(tokens)
+	if err != nil {
+		return Commit{}, nil, fmt.Errorf("commit %s: %w", commit.Hash, err)
+	}
+	return commit, changes, nil
+}
+
+// parseNumstat parses the --raw entries of a commit, ":modes hashes status"
+// followed by one path or, for a rename, two, and then its "added\tdeleted\tpath"
+// entries up to the next commit header. A rename has an empty numstat path
+// followed by the old and new path.
+func parseNumstat(tokens *logTokens) ([]FileChange, error) {
+	kinds := make(map[string]ChangeKind)
+	var changes []FileChange
+	for {
+		token, err := tokens.peek()
+		if err == io.EOF {
+			return changes, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		entry := strings.TrimLeft(token, "\n")
+		if strings.HasPrefix(entry, ":") {
+			tokens.next()
+			if err := parseRawEntry(tokens, entry, kinds); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		parts := strings.SplitN(entry, "\t", 3)
+		// a commit hash has no tabs, so this is the next header
+		if len(parts) != 3 {
+			if entry == "" {
+				tokens.next()
+				continue
+			}
+			return changes, nil
+		}
+		tokens.next()
+
+		change := FileChange{Path: parts[2]}
+		// binary files have "-" counts
+		change.Added, _ = strconv.Atoi(parts[0])
+		change.Deleted, _ = strconv.Atoi(parts[1])
+
+		if change.Path == "" {
+			from, fromErr := tokens.next()
+			to, toErr := tokens.next()
+			if fromErr != nil || toErr != nil {
+				return nil, errors.New("truncated rename in numstat output")
+			}
+			change.From, change.Path = from, to
+		}
+		change.Kind = kinds[change.Path]
+		changes = append(changes, change)
+	}
+}
+
+// parseRawEntry records the kind of change of a --raw entry whose status
+// part is entry and whose paths follow in tokens.
+func parseRawEntry(tokens *logTokens, entry string, kinds map[string]ChangeKind) error {
+	fields := strings.Fields(entry)
+	if len(fields) != 5 || fields[4] == "" {
+		return fmt.Errorf("could not parse raw entry %q", entry)
+	}
+	status := fields[4][0]
+
+	path, err := tokens.next()
+	if err != nil {
+		return errors.New("truncated raw entry")
+	}
+	// renames and copies name the old path first
+	if status == 'R' || status == 'C' {
+		if path, err = tokens.next(); err != nil {
+			return errors.New("truncated raw entry")
+		}
+	}
+
+	switch status {
+	case 'A':
+		kinds[path] = ChangeAdded
+	case 'D':
+		kinds[path] = ChangeDeleted
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// CoAuthorCredit selects how Co-authored-by trailers are credited.
+type CoAuthorCredit int
+
+const (
+	// CoAuthorsIgnored credits only the commit author.
+	CoAuthorsIgnored CoAuthorCredit = iota
+	// CoAuthorsFull credits the author and every co-author with a weight
+	// of one each.
+	CoAuthorsFull
+	// CoAuthorsSplit splits a weight of one evenly between the author and
+	// the co-authors.
+	CoAuthorsSplit
+)
+
+var coAuthorCreditNames = map[CoAuthorCredit]string{
+	CoAuthorsIgnored: "off",
+	CoAuthorsFull:    "full",
+	CoAuthorsSplit:   "split",
+}
+
+func (c CoAuthorCredit) String() string {
+	if name, ok := coAuthorCreditNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("CoAuthorCredit(%d)", int(c))
+}
+
+// ParseCoAuthorCredit parses "off", "full" or "split".
+func ParseCoAuthorCredit(s string) (CoAuthorCredit, error) {
+	for credit, name := range coAuthorCreditNames {
+		if name == s {
+			return credit, nil
+		}
+	}
+	return CoAuthorsIgnored, fmt.Errorf("unknown co-author credit %q, want off, full or split", s)
+}
+
+var coAuthorTrailer = regexp.MustCompile(`(?i)^co-authored-by:\s*(.*?)\s*<([^>]*)>\s*$`)
+
+// coAuthors returns the identities named in the Co-authored-by trailers of
+// a commit message.
+func coAuthors(message string) []Identity {
+	var ids []Identity
+	scanner := bufio.NewScanner(strings.NewReader(message))
+	for scanner.Scan() {
+		matches := coAuthorTrailer.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if matches != nil {
+			ids = append(ids, Identity{Name: matches[1], Email: matches[2]})
+		}
+	}
+	return ids
+}
+
+// credited is one person credited for a commit with a weight.
+type credited struct {
+	original Identity
+	resolved Identity
+	weight   float64
+}
+
+// creditCommit lists who is credited for a commit: its author and, unless
+// credit is CoAuthorsIgnored, the distinct co-authors from its trailers that
+// filter does not exclude.
+func creditCommit(author Identity, message string, credit CoAuthorCredit, identities *identityResolver, filter *CommitFilter) []credited {
+	people := []credited{{original: author, resolved: identities.resolve(author), weight: 1}}
+	if credit == CoAuthorsIgnored {
+		return people
+	}
+
+	seen := map[Identity]bool{people[0].resolved: true}
+	for _, id := range coAuthors(message) {
+		if filter.excludesAuthor(id) {
+			continue
+		}
+		resolved := identities.resolve(id)
+		if seen[resolved] {
+			continue
+		}
+		seen[resolved] = true
+		people = append(people, credited{original: id, resolved: resolved, weight: 1})
+	}
+
+	if credit == CoAuthorsSplit {
+		for i := range people {
+			people[i].weight = 1 / float64(len(people))
+		}
+	}
+	return people
+}
@@ -0,0 +1,55 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoAuthors(t *testing.T) {
+	message := `Add pairing support
+
+Some description.
+co-authored-by: Bob <bob@example.com>
+Co-authored-by:   Carol Jones <carol@example.com>  
+Signed-off-by: Alice <alice@example.com>
+`
+	expected := []Identity{{"Bob", "bob@example.com"}, {"Carol Jones", "carol@example.com"}}
+	assert.Equal(t, expected, coAuthors(message))
+	assert.Empty(t, coAuthors("no trailers"))
+}
+
+func TestParseCoAuthorCredit(t *testing.T) {
+	for _, credit := range []CoAuthorCredit{CoAuthorsIgnored, CoAuthorsFull, CoAuthorsSplit} {
+		parsed, err := ParseCoAuthorCredit(credit.String())
+		require.NoError(t, err)
+		assert.Equal(t, credit, parsed)
+	}
+	_, err := ParseCoAuthorCredit("half")
+	assert.Error(t, err)
+}
+
+func TestHistoryCoAuthors(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	r.commitAs("alice", "alice@example.com", "pair\n\nCo-authored-by: bob <bob@example.com>\nCo-authored-by: alice <alice@example.com>\n",
+		map[string]string{"a.txt": "a\n"})
+
+	credits := func(credit CoAuthorCredit) []string {
+		commits, err := History(ctx, HistoryOptions{RepoPath: r.path, CoAuthors: credit})
+		require.NoError(t, err)
+		var result []string
+		for _, c := range commits {
+			result = append(result, fmt.Sprintf("%s:%s:%.1f", c.Author, c.Filename, c.Weight))
+		}
+		return result
+	}
+
+	assert.Equal(t, []string{"alice:a.txt:1.0"}, credits(CoAuthorsIgnored))
+	// alice naming herself as a co-author is not counted twice
+	assert.Equal(t, []string{"alice:a.txt:1.0", "bob:a.txt:1.0"}, credits(CoAuthorsFull))
+	assert.Equal(t, []string{"alice:a.txt:0.5", "bob:a.txt:0.5"}, credits(CoAuthorsSplit))
+}
@@ -0,0 +1,18 @@
+package git
+
+import "errors"
+
+// Errors returned by History. They are wrapped with details about the
+// repository or ref involved, so match them with errors.Is.
+var (
+	// ErrNotRepository means the path is missing or not a git repository.
+	ErrNotRepository = errors.New("not a git repository")
+	// ErrEmptyRepository means the repository has no commits yet.
+	ErrEmptyRepository = errors.New("repository has no commits")
+	// ErrUnknownRef means the requested branch, tag or commit does not exist.
+	ErrUnknownRef = errors.New("unknown ref")
+	// ErrCloneFailed means the repository could not be cloned from its URL.
+	ErrCloneFailed = errors.New("clone failed")
+	// ErrNoMergeBase means two refs share no history.
+	ErrNoMergeBase = errors.New("no merge base")
+)
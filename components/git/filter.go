@@ -0,0 +1,122 @@
+package git
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// builtinBots matches the names or emails of common automation accounts.
+var builtinBots = []struct {
+	name    string
+	pattern string
+}{
+	{"dependabot", `(?i)dependabot`},
+	{"renovate", `(?i)^renovate(\[bot\])?$`},
+	{"github-actions", `(?i)github-actions`},
+	{"semantic-release", `(?i)semantic-release-bot`},
+	{"greenkeeper", `(?i)greenkeeper`},
+	{"snyk", `(?i)snyk-bot`},
+	{"pre-commit-ci", `(?i)pre-commit-ci`},
+	{"bot-suffix", `(?i)\[bot\]`},
+}
+
+// filterRule excludes commits whose author or message matches a pattern.
+type filterRule struct {
+	name    string
+	message bool
+	pattern *regexp.Regexp
+}
+
+// CommitFilter drops commits made by automation, or whose message matches
+// a pattern, before they are analyzed. It counts how many commits each rule
+// removed. A nil *CommitFilter keeps every commit.
+type CommitFilter struct {
+	rules   []filterRule
+	removed map[string]int
+}
+
+// NewCommitFilter returns a filter without rules.
+func NewCommitFilter() *CommitFilter {
+	return &CommitFilter{removed: make(map[string]int)}
+}
+
+// ExcludeBots adds the built-in rules for Dependabot, Renovate, GitHub
+// Actions, release bots and other "[bot]" accounts.
+func (f *CommitFilter) ExcludeBots() {
+	for _, bot := range builtinBots {
+		f.rules = append(f.rules, filterRule{
+			name:    "bot:" + bot.name,
+			pattern: regexp.MustCompile(bot.pattern),
+		})
+	}
+}
+
+// ExcludeAuthor adds a rule dropping commits whose author name or email
+// matches the regular expression.
+func (f *CommitFilter) ExcludeAuthor(pattern string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid author pattern: %w", err)
+	}
+	f.rules = append(f.rules, filterRule{name: "author:" + pattern, pattern: re})
+	return nil
+}
+
+// ExcludeMessage adds a rule dropping commits whose message matches the
+// regular expression.
+func (f *CommitFilter) ExcludeMessage(pattern string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid message pattern: %w", err)
+	}
+	f.rules = append(f.rules, filterRule{name: "message:" + pattern, message: true, pattern: re})
+	return nil
+}
+
+// Removed returns how many commits each rule removed, keyed by rule name.
+// A commit matching several rules counts for the first one only.
+func (f *CommitFilter) Removed() map[string]int {
+	removed := make(map[string]int, len(f.removed))
+	for name, count := range f.removed {
+		removed[name] = count
+	}
+	return removed
+}
+
+// excludes reports whether a commit should be dropped, and counts it
+// against the rule that matched. It is only asked about commits that would
+// otherwise be analyzed, so that the counts are not inflated by commits
+// other filters drop anyway.
+func (f *CommitFilter) excludes(author Identity, message string) bool {
+	if f == nil {
+		return false
+	}
+	for _, rule := range f.rules {
+		if rule.matches(author, message) {
+			f.removed[rule.name]++
+			return true
+		}
+	}
+	return false
+}
+
+// excludesAuthor reports whether an identity, such as a co-author, matches
+// an author rule. Matches are not counted as removed commits.
+func (f *CommitFilter) excludesAuthor(id Identity) bool {
+	if f == nil {
+		return false
+	}
+	for _, rule := range f.rules {
+		if !rule.message && rule.matches(id, "") {
+			return true
+		}
+	}
+	return false
+}
+
+func (r filterRule) matches(author Identity, message string) bool {
+	if r.message {
+		return r.pattern.MatchString(message)
+	}
+	return r.pattern.MatchString(author.Name) || r.pattern.MatchString(author.Email)
+}
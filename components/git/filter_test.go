@@ -0,0 +1,53 @@
+package git
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryFilter(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	r.commit("alice", map[string]string{"a.txt": "a\n"})
+	r.commitAs("dependabot[bot]", "49699333+dependabot[bot]@users.noreply.github.com", "Bump lodash",
+		map[string]string{"package.json": "1\n"})
+	r.commitAs("renovate[bot]", "29139614+renovate[bot]@users.noreply.github.com", "Update deps", map[string]string{"package.json": "2\n"})
+	// a person whose name merely contains "renovate" is no bot
+	r.commitAs("Renovate Fan", "fan@example.com", "Tidy", map[string]string{"b.txt": "b\n"})
+	r.commitAs("Release Robot", "release@example.com", "Release v1.2.0", map[string]string{"CHANGELOG.md": "v1.2.0\n"})
+	r.commitAs("bob", "bob@example.com", "chore: release v1.3.0\n\nCo-authored-by: github-actions <41898282+github-actions[bot]@users.noreply.github.com>",
+		map[string]string{"CHANGELOG.md": "v1.3.0\n"})
+	r.commitAs("bob", "bob@example.com", "pair\n\nCo-authored-by: github-actions <41898282+github-actions[bot]@users.noreply.github.com>",
+		map[string]string{"a.txt": "a\nb\n"})
+
+	filter := NewCommitFilter()
+	filter.ExcludeBots()
+	require.NoError(t, filter.ExcludeAuthor(`^Release Robot$`))
+	require.NoError(t, filter.ExcludeMessage(`^chore: release`))
+
+	commits, err := History(ctx, HistoryOptions{RepoPath: r.path, Filter: filter, CoAuthors: CoAuthorsFull})
+	require.NoError(t, err)
+
+	// the bot co-author of bob's second commit is not credited either
+	assert.Equal(t, []string{"Renovate Fan:b.txt", "alice:a.txt", "bob:a.txt"}, filesByAuthor(commits))
+	assert.Equal(t, map[string]int{
+		"bot:dependabot":          1,
+		"bot:renovate":            1,
+		"author:^Release Robot$":  1,
+		"message:^chore: release": 1,
+	}, filter.Removed())
+
+	// commits dropped by the path filters anyway are not counted
+	filter = NewCommitFilter()
+	filter.ExcludeBots()
+	require.NoError(t, filter.ExcludeMessage(`^chore: release`))
+	_, err = History(ctx, HistoryOptions{RepoPath: r.path, Filter: filter, Exclude: []string{"package.json"}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"message:^chore: release": 1}, filter.Removed())
+
+	assert.Error(t, filter.ExcludeAuthor("("))
+	assert.Error(t, filter.ExcludeMessage("["))
+}
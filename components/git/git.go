@@ -1,71 +1,212 @@
 package git
 
 import (
+	"context"
+	"fmt"
 	"log"
-	"os"
 
-	git "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
 
 	"techdebt/components/commitinfo"
 )
 
+// GetCommits returns one CommitInfo per file changed by each commit
+// reachable from HEAD. It exits the program if the history cannot be read;
+// use History to handle errors instead.
 func GetCommits(repoPath string) []commitinfo.CommitInfo {
-	repoURL := "https://github.com/user/repo.git" // Replace with your repo URL
-
-	// Clone repo if it doesn't exist locally
-	if _, err := os.Stat(repoPath); os.IsNotExist(err) {
-		_, err := git.PlainClone(repoPath, false, &git.CloneOptions{
-			URL:      repoURL,
-			Progress: os.Stdout,
-		})
-		if err != nil {
-			log.Fatalf("Failed to clone repository: %v", err)
+	commits, err := History(context.Background(), HistoryOptions{RepoPath: repoPath})
+	if err != nil {
+		log.Fatalf("Failed to read commit history: %v", err)
+	}
+	return commits
+}
+
+// FileChange is one file touched by a commit. From holds the previous path
+// when the change is a detected rename. Added and Deleted count changed
+// lines when line stats were requested.
+type FileChange struct {
+	Path    string     `json:"path"`
+	From    string     `json:"from,omitempty"`
+	Kind    ChangeKind `json:"kind,omitempty"`
+	Added   int        `json:"added,omitempty"`
+	Deleted int        `json:"deleted,omitempty"`
+}
+
+// ChangeKind is whether a commit created, removed or modified a file. A
+// rename modifies it.
+type ChangeKind int
+
+const (
+	ChangeModified ChangeKind = iota
+	ChangeAdded
+	ChangeDeleted
+)
+
+var changeKindNames = map[ChangeKind]string{
+	ChangeModified: "modified",
+	ChangeAdded:    "added",
+	ChangeDeleted:  "deleted",
+}
+
+func (k ChangeKind) String() string {
+	if name, ok := changeKindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("ChangeKind(%d)", int(k))
+}
+
+// differ computes the files changed by commits, reusing cached results.
+type differ struct {
+	// options are the go-git diff options, nil without rename detection
+	options *object.DiffTreeOptions
+	// firstParent diffs merges against their first parent only
+	firstParent bool
+	// lineStats counts added and deleted lines per file
+	lineStats bool
+	// cache is nil when caching is off
+	cache *changeCache
+}
+
+// key describes the options that affect the computed changes.
+func (d *differ) key() string {
+	key := "plain"
+	if d.options != nil && d.options.DetectRenames {
+		key = fmt.Sprintf("renames:%d", d.options.RenameScore)
+	}
+	if d.firstParent {
+		key += ";first-parent"
+	}
+	if d.lineStats {
+		key += ";lines"
+	}
+	return key
+}
+
+// changedFiles returns the files changed by c, from the cache if possible.
+func (d *differ) changedFiles(ctx context.Context, c *object.Commit) ([]FileChange, error) {
+	if d.cache != nil {
+		if files, ok := d.cache.get(c.Hash.String()); ok {
+			return files, nil
 		}
 	}
 
-	// Open the local repository
-	repo, err := git.PlainOpen(repoPath)
+	changes, err := commitChanges(ctx, c, d.options, d.firstParent)
 	if err != nil {
-		log.Fatalf("Failed to open repository: %v", err)
+		return nil, err
 	}
-
-	// Get the commit history
-	ref, err := repo.Head()
+	// the line stats of a merge are those of the diff against its first
+	// parent, which include everything the other side changed
+	merge := c.NumParents() > 1 && !d.firstParent
+	files, err := toFileChanges(ctx, changes, d.lineStats && !merge)
 	if err != nil {
-		log.Fatalf("Failed to get HEAD reference: %v", err)
+		return nil, err
 	}
 
-	commitIter, err := repo.Log(&git.LogOptions{From: ref.Hash()})
+	if d.cache != nil {
+		d.cache.put(c.Hash.String(), files)
+	}
+	return files, nil
+}
+
+// commitChanges returns the changes a commit made relative to its parents.
+// The root commit is diffed against an empty tree, so every file it adds
+// counts as changed. For a merge commit only the paths that differ from
+// every parent are returned, i.e. the changes made while resolving the merge
+// rather than the changes brought in from the merged branches, unless
+// firstParent asks for the diff against the first parent alone. Changes of
+// merges are taken from the diff against the first parent, so their line
+// stats are not those of the resolution and are left out. A nil
+// diffOptions disables rename detection.
+func commitChanges(ctx context.Context, c *object.Commit, diffOptions *object.DiffTreeOptions, firstParent bool) (object.Changes, error) {
+	tree, err := c.Tree()
 	if err != nil {
-		log.Fatalf("Failed to get commit history: %v", err)
+		return nil, err
 	}
 
-	var commits []commitinfo.CommitInfo
+	if c.NumParents() == 0 {
+		return object.DiffTreeWithOptions(ctx, nil, tree, diffOptions)
+	}
 
-	// Iterate over each commit
-	err = commitIter.ForEach(func(c *object.Commit) error {
-		// Get the files modified in this commit
-		files, err := c.Files()
+	var changes object.Changes
+	seen := make(map[string]int)
+	parentIndex := 0
+	err = c.Parents().ForEach(func(parent *object.Commit) error {
+		parentTree, err := parent.Tree()
 		if err != nil {
 			return err
 		}
 
-		// Add each file with commit metadata to commits slice
-		files.ForEach(func(f *object.File) error {
-			commitInfo := commitinfo.CommitInfo{
-				Author:    c.Author.Name,
-				Filename:  f.Name,
-				Timestamp: c.Author.When,
-			}
-			commits = append(commits, commitInfo)
-			return nil
-		})
+		parentChanges, err := object.DiffTreeWithOptions(ctx, parentTree, tree, diffOptions)
+		if err != nil {
+			return err
+		}
+
+		if parentIndex == 0 {
+			changes = parentChanges
+		}
 
+		// count the parents each path was changed against
+		for _, change := range parentChanges {
+			if seen[changePath(change)] == parentIndex {
+				seen[changePath(change)]++
+			}
+		}
+		parentIndex++
+		if firstParent {
+			return storer.ErrStop
+		}
 		return nil
 	})
 	if err != nil {
-		log.Fatalf("Failed to iterate commits: %v", err)
+		return nil, err
 	}
-	return commits
+
+	result := changes[:0]
+	for _, change := range changes {
+		if seen[changePath(change)] == parentIndex {
+			result = append(result, change)
+		}
+	}
+	return result, nil
+}
+
+// changePath is the path a change leaves the file at. Deletions only have
+// a "from" side.
+func changePath(change *object.Change) string {
+	if change.To.Name == "" {
+		return change.From.Name
+	}
+	return change.To.Name
+}
+
+// toFileChanges converts go-git changes, counting changed lines if
+// lineStats is set.
+func toFileChanges(ctx context.Context, changes object.Changes, lineStats bool) ([]FileChange, error) {
+	files := make([]FileChange, 0, len(changes))
+	for _, change := range changes {
+		file := FileChange{Path: changePath(change)}
+		switch {
+		case change.From.Name == "":
+			file.Kind = ChangeAdded
+		case change.To.Name == "":
+			file.Kind = ChangeDeleted
+		case change.From.Name != change.To.Name:
+			file.From = change.From.Name
+		}
+
+		if lineStats {
+			patch, err := change.PatchContext(ctx)
+			if err != nil {
+				return nil, err
+			}
+			for _, stat := range patch.Stats() {
+				file.Added += stat.Addition
+				file.Deleted += stat.Deletion
+			}
+		}
+
+		files = append(files, file)
+	}
+	return files, nil
 }
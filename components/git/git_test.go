@@ -0,0 +1,144 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"techdebt/components/commitinfo"
+)
+
+// testRepo is a small synthetic repository built commit by commit.
+type testRepo struct {
+	t    testing.TB
+	path string
+	repo *git.Repository
+	when time.Time
+}
+
+func newTestRepo(t testing.TB) *testRepo {
+	t.Helper()
+	path := t.TempDir()
+	repo, err := git.PlainInit(path, false)
+	require.NoError(t, err)
+	return &testRepo{
+		t:    t,
+		path: path,
+		repo: repo,
+		when: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+// commit writes files (an empty content deletes the file) and commits them
+// as author. Extra parents turn the commit into a merge.
+func (r *testRepo) commit(author string, files map[string]string, parents ...plumbing.Hash) plumbing.Hash {
+	r.t.Helper()
+	return r.commitAs(author, author+"@example.com", "commit by "+author, files, parents...)
+}
+
+// commitAs is commit with full control over the author and message.
+func (r *testRepo) commitAs(name, email, message string, files map[string]string, parents ...plumbing.Hash) plumbing.Hash {
+	r.t.Helper()
+	wt, err := r.repo.Worktree()
+	require.NoError(r.t, err)
+
+	for file, content := range files {
+		full := filepath.Join(r.path, file)
+		if content == "" {
+			_, err = wt.Remove(file)
+			require.NoError(r.t, err)
+			continue
+		}
+		require.NoError(r.t, os.MkdirAll(filepath.Dir(full), 0o755))
+		require.NoError(r.t, os.WriteFile(full, []byte(content), 0o644))
+		_, err = wt.Add(file)
+		require.NoError(r.t, err)
+	}
+
+	if len(parents) > 0 {
+		head, err := r.repo.Head()
+		require.NoError(r.t, err)
+		parents = append([]plumbing.Hash{head.Hash()}, parents...)
+	}
+
+	r.when = r.when.Add(time.Hour)
+	hash, err := wt.Commit(message, &git.CommitOptions{
+		Author:            &object.Signature{Name: name, Email: email, When: r.when},
+		Parents:           parents,
+		AllowEmptyCommits: true,
+	})
+	require.NoError(r.t, err)
+	return hash
+}
+
+// filesByAuthor flattens commit records into "author:file" pairs.
+func filesByAuthor(commits []commitinfo.CommitInfo) []string {
+	var pairs []string
+	for _, c := range commits {
+		pairs = append(pairs, c.Author+":"+c.Filename)
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
+func TestGetCommitsOnlyChangedFiles(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("alice", map[string]string{"a.txt": "a\n", "b.txt": "b\n"})
+	r.commit("bob", map[string]string{"b.txt": "b\nb\n"})
+	r.commit("carol", map[string]string{"c.txt": "c\n"})
+
+	actual := filesByAuthor(GetCommits(r.path))
+
+	// the tree at carol's commit holds three files, but she only touched one
+	expected := []string{"alice:a.txt", "alice:b.txt", "bob:b.txt", "carol:c.txt"}
+	assert.Equal(t, expected, actual)
+}
+
+func TestGetCommitsDeletedFile(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("alice", map[string]string{"a.txt": "a\n", "b.txt": "b\n"})
+	r.commit("bob", map[string]string{"a.txt": ""})
+
+	actual := filesByAuthor(GetCommits(r.path))
+
+	expected := []string{"alice:a.txt", "alice:b.txt", "bob:a.txt"}
+	assert.Equal(t, expected, actual)
+}
+
+func TestGetCommitsMergeResolution(t *testing.T) {
+	r := mergeRepo(t)
+
+	actual := filesByAuthor(GetCommits(r.path))
+
+	// b.txt in the merge matches the side parent, so carol is not credited for it
+	expected := []string{"alice:a.txt", "alice:a.txt", "alice:b.txt", "bob:b.txt", "carol:a.txt"}
+	assert.Equal(t, expected, actual)
+}
+
+func TestHistoryRenameOfRecreatedFile(t *testing.T) {
+	requireGitBinary(t)
+	ctx := context.Background()
+	r := newTestRepo(t)
+	r.commit("alice", map[string]string{"a.go": lines(20)})
+	r.commit("bob", map[string]string{"a.go": ""})
+	r.commit("carol", map[string]string{"a.go": lines(30)})
+	r.commit("dave", map[string]string{"a.go": "", "b.go": lines(30)})
+
+	for _, backend := range []Backend{BackendGoGit, BackendCLI} {
+		t.Run(backend.String(), func(t *testing.T) {
+			commits, err := History(ctx, HistoryOptions{RepoPath: r.path, DetectRenames: true, Backend: backend})
+			require.NoError(t, err)
+			// the a.go alice created and bob deleted is another file
+			assert.Equal(t, []string{"alice:a.go", "bob:a.go", "carol:b.go", "dave:b.go"}, filesByAuthor(commits))
+		})
+	}
+}
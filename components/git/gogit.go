@@ -0,0 +1,277 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// goGitSource reads history in-process with go-git.
+type goGitSource struct {
+	repo   *git.Repository
+	start  plumbing.Hash
+	opts   HistoryOptions
+	differ *differ
+
+	// go-git storage is not safe for concurrent use, so diffs run on
+	// handles of their own, kept in idle between diffs
+	mu   sync.Mutex
+	idle []*git.Repository
+}
+
+func newGoGitSource(ctx context.Context, opts HistoryOptions) (*goGitSource, error) {
+	repo, err := openRepository(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	start, err := resolveStart(repo, opts.Ref)
+	if err != nil {
+		return nil, err
+	}
+	if !opts.AsOf.IsZero() {
+		if start, err = commitAsOf(repo, start, opts.AsOf); err != nil {
+			return nil, err
+		}
+	}
+
+	differ := &differ{
+		options:     diffTreeOptions(opts),
+		firstParent: opts.Merges == MergesFirstParent,
+		lineStats:   opts.LineStats,
+	}
+	if opts.Cache {
+		if differ.cache, err = openCache(repo, differ.key()); err != nil {
+			return nil, err
+		}
+	}
+
+	return &goGitSource{repo: repo, start: start, opts: opts, differ: differ}, nil
+}
+
+func (s *goGitSource) Walk(ctx context.Context, visit func(Commit, ChangesFunc) error) error {
+	commitIter, err := logCommits(s.repo, s.start, s.opts)
+	if err != nil {
+		return err
+	}
+	defer commitIter.Close()
+
+	err = commitIter.ForEach(func(c *object.Commit) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		commit := Commit{
+			Hash:    c.Hash.String(),
+			Author:  Identity{Name: c.Author.Name, Email: c.Author.Email},
+			When:    c.Author.When,
+			Message: c.Message,
+			Parents: c.NumParents(),
+		}
+		err := visit(commit, func() ([]FileChange, error) {
+			return s.changedFiles(ctx, c.Hash)
+		})
+		if errors.Is(err, errStopWalk) {
+			return storer.ErrStop
+		}
+		return err
+	})
+	return err
+}
+
+func (s *goGitSource) Authors(ctx context.Context) ([]Identity, error) {
+	commitIter, err := logCommits(s.repo, s.start, s.opts)
+	if err != nil {
+		return nil, err
+	}
+	defer commitIter.Close()
+
+	var authors []Identity
+	err = commitIter.ForEach(func(c *object.Commit) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		authors = append(authors, Identity{Name: c.Author.Name, Email: c.Author.Email})
+		return nil
+	})
+	return authors, err
+}
+
+// Close writes back the change cache, including changes computed after
+// Walk returned.
+func (s *goGitSource) Close() error {
+	s.idle = nil
+	if s.differ.cache != nil {
+		return s.differ.cache.save(s.repo)
+	}
+	return nil
+}
+
+// changedFiles diffs a commit on a repository handle no other goroutine is
+// using.
+func (s *goGitSource) changedFiles(ctx context.Context, hash plumbing.Hash) ([]FileChange, error) {
+	s.mu.Lock()
+	var repo *git.Repository
+	if n := len(s.idle); n > 0 {
+		repo, s.idle = s.idle[n-1], s.idle[:n-1]
+	}
+	s.mu.Unlock()
+
+	if repo == nil {
+		var err error
+		if repo, err = git.PlainOpen(s.opts.RepoPath); err != nil {
+			return nil, fmt.Errorf("could not open repository %s: %w", s.opts.RepoPath, err)
+		}
+	}
+	defer func() {
+		s.mu.Lock()
+		s.idle = append(s.idle, repo)
+		s.mu.Unlock()
+	}()
+
+	c, err := repo.CommitObject(hash)
+	if err != nil {
+		return nil, fmt.Errorf("could not read commit %s: %w", hash, err)
+	}
+	return s.differ.changedFiles(ctx, c)
+}
+
+func (s *goGitSource) ReadFile(ctx context.Context, name string) ([]byte, error) {
+	commit, err := s.repo.CommitObject(s.start)
+	if err != nil {
+		return nil, fmt.Errorf("could not read commit %s: %w", s.start, err)
+	}
+	file, err := commit.File(name)
+	if errors.Is(err, object.ErrFileNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", name, err)
+	}
+	content, err := file.Contents()
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", name, err)
+	}
+	return []byte(content), nil
+}
+
+func (s *goGitSource) ReadHeader(ctx context.Context, name string, size int) ([]byte, error) {
+	commit, err := s.repo.CommitObject(s.start)
+	if err != nil {
+		return nil, fmt.Errorf("could not read commit %s: %w", s.start, err)
+	}
+	file, err := commit.File(name)
+	if errors.Is(err, object.ErrFileNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", name, err)
+	}
+	reader, err := file.Reader()
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", name, err)
+	}
+	defer reader.Close()
+	content, err := io.ReadAll(io.LimitReader(reader, int64(size)))
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", name, err)
+	}
+	return content, nil
+}
+
+// logCommits returns the commits to visit, newest first.
+func logCommits(repo *git.Repository, from plumbing.Hash, opts HistoryOptions) (object.CommitIter, error) {
+	if opts.Merges == MergesFirstParent {
+		start, err := repo.CommitObject(from)
+		if err != nil {
+			return nil, fmt.Errorf("could not read commit log: %w", err)
+		}
+		return &firstParentIter{next: start, since: opts.Since, until: opts.Until}, nil
+	}
+
+	logOptions := &git.LogOptions{From: from, Order: git.LogOrderCommitterTime}
+	if !opts.Since.IsZero() {
+		logOptions.Since = &opts.Since
+	}
+	if !opts.Until.IsZero() {
+		logOptions.Until = &opts.Until
+	}
+
+	commitIter, err := repo.Log(logOptions)
+	if err != nil {
+		return nil, fmt.Errorf("could not read commit log: %w", err)
+	}
+	return commitIter, nil
+}
+
+// openRepository opens the repository at opts.RepoPath, cloning it from
+// opts.CloneURL first when the path does not exist.
+func openRepository(ctx context.Context, opts HistoryOptions) (*git.Repository, error) {
+	if _, err := os.Stat(opts.RepoPath); os.IsNotExist(err) && opts.CloneURL != "" {
+		repo, err := git.PlainCloneContext(ctx, opts.RepoPath, false, &git.CloneOptions{
+			URL: opts.CloneURL,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrCloneFailed, opts.CloneURL, err)
+		}
+		return repo, nil
+	}
+
+	repo, err := git.PlainOpen(opts.RepoPath)
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		return nil, fmt.Errorf("%w: %s", ErrNotRepository, opts.RepoPath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not open repository %s: %w", opts.RepoPath, err)
+	}
+	return repo, nil
+}
+
+// resolveStart turns ref into the commit hash the walk starts from. An empty
+// ref means HEAD.
+func resolveStart(repo *git.Repository, ref string) (plumbing.Hash, error) {
+	if ref == "" {
+		head, err := repo.Head()
+		if errors.Is(err, plumbing.ErrReferenceNotFound) {
+			return plumbing.ZeroHash, ErrEmptyRepository
+		}
+		if err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("could not read HEAD: %w", err)
+		}
+		return head.Hash(), nil
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("%w: %s", ErrUnknownRef, ref)
+	}
+	return *hash, nil
+}
+
+// commitAsOf returns the newest commit reachable from start that was
+// committed at or before asOf.
+func commitAsOf(repo *git.Repository, start plumbing.Hash, asOf time.Time) (plumbing.Hash, error) {
+	commitIter, err := repo.Log(&git.LogOptions{From: start, Order: git.LogOrderCommitterTime, Until: &asOf})
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("could not read commit log: %w", err)
+	}
+	defer commitIter.Close()
+
+	c, err := commitIter.Next()
+	if errors.Is(err, io.EOF) {
+		return plumbing.ZeroHash, fmt.Errorf("%w as of %s", ErrEmptyRepository, asOf.Format(time.RFC3339))
+	}
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("could not read commit log: %w", err)
+	}
+	return c.Hash, nil
+}
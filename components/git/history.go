@@ -0,0 +1,216 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"strings"
+	"time"
+
+	"techdebt/components/commitinfo"
+)
+
+// HistoryOptions controls which part of a repository's history is read.
+// The zero value of every field except RepoPath means "no restriction".
+type HistoryOptions struct {
+	// RepoPath is the local repository to read.
+	RepoPath string
+	// CloneURL is cloned into RepoPath when RepoPath does not exist yet.
+	CloneURL string
+	// Ref is the branch, tag or commit the walk starts from. Defaults to HEAD.
+	Ref string
+	// AsOf moves the start of the walk back to the newest commit of Ref
+	// committed at or before this time, so the history, and the files read
+	// from the repository, are those the repository had then.
+	AsOf time.Time
+	// Since and Until bound the commit dates, both inclusive.
+	Since time.Time
+	Until time.Time
+	// MaxCount stops the walk after this many commits have produced records.
+	MaxCount int
+	// Paths keeps only files equal to, or inside a directory named by, one
+	// of these slash-separated paths.
+	Paths []string
+	// Include keeps only files matching one of these patterns, and Exclude
+	// drops the files matching any of them. Patterns use .gitignore syntax,
+	// e.g. "*.pb.go" or "vendor/".
+	Include []string
+	Exclude []string
+	// UseIgnoreFile drops the files listed in the repository's IgnoreFile.
+	UseIgnoreFile bool
+	// ExcludeGenerated drops files marked linguist-generated or
+	// linguist-vendored in the repository's .gitattributes, and files that
+	// start with a "Code generated ... DO NOT EDIT" header.
+	ExcludeGenerated bool
+	// DetectRenames attributes the history of a renamed file to its current
+	// path. RenameScore is the similarity percentage a rename needs and
+	// defaults to DefaultRenameScore.
+	DetectRenames bool
+	RenameScore   uint
+	// Cache keeps the files changed by each commit in the repository's git
+	// directory and reuses them on later runs. Only the go-git backend
+	// caches; the git backend is fast enough without.
+	Cache bool
+	// UseMailmap resolves authors through the repository's .mailmap, and
+	// AliasFile names an extra file in mailmap format applied on top of it.
+	UseMailmap bool
+	AliasFile  string
+	// MergeByEmail treats authors sharing an email as one person, named
+	// after the first name seen for that email.
+	MergeByEmail bool
+	// CoAuthors selects whether people named in Co-authored-by trailers
+	// are credited as well. See CoAuthorCredit.
+	CoAuthors CoAuthorCredit
+	// Filter drops commits by bots or with matching messages. It counts
+	// the commits it removed that change files the other options keep.
+	Filter *CommitFilter
+	// Merges selects how merge commits are walked and attributed.
+	Merges MergeMode
+	// LineStats counts the lines added and deleted in every file change.
+	LineStats bool
+	// Backend selects how the repository is read. See Backend.
+	Backend Backend
+	// Workers is the number of commits diffed concurrently. Zero uses one
+	// worker per CPU. Records come out in the same order either way.
+	Workers int
+}
+
+// History walks the history of a repository and returns one CommitInfo per
+// file changed by each commit and person credited for it, newest commit
+// first. It holds the whole history in memory; use Commits to process large
+// repositories.
+func History(ctx context.Context, opts HistoryOptions) ([]commitinfo.CommitInfo, error) {
+	var commits []commitinfo.CommitInfo
+	for commit, err := range Commits(ctx, opts) {
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, commit)
+	}
+	return commits, nil
+}
+
+// Commits streams the records History would return, yielding each one as
+// soon as its commit has been diffed. An error is yielded at most once and
+// ends the sequence. Stopping the range loop stops the walk.
+func Commits(ctx context.Context, opts HistoryOptions) iter.Seq2[commitinfo.CommitInfo, error] {
+	return func(yield func(commitinfo.CommitInfo, error) bool) {
+		if err := walk(ctx, opts, yield); err != nil {
+			yield(commitinfo.CommitInfo{}, err)
+		}
+	}
+}
+
+// walk visits the commits selected by opts and passes their records to
+// yield. It returns nil without further calls once yield returns false.
+func walk(ctx context.Context, opts HistoryOptions, yield func(commitinfo.CommitInfo, error) bool) error {
+	source, err := OpenSource(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	identities, err := newIdentityResolver(ctx, source, opts)
+	if err != nil {
+		return err
+	}
+
+	paths, err := newPathFilter(ctx, source, opts)
+	if err != nil {
+		return err
+	}
+
+	var count int
+	renames := newRenameTracker()
+
+	emit := func(d *pendingDiff) error {
+		c := d.commit
+		if d.err != nil {
+			return fmt.Errorf("could not diff commit %s: %w", c.Hash, d.err)
+		}
+
+		type keptChange struct {
+			name   string
+			change FileChange
+		}
+		var kept []keptChange
+		for _, change := range d.files {
+			// renames are tracked through every commit, filtered or not
+			name := renames.resolve(change)
+			if !matchesPaths(name, opts.Paths) {
+				continue
+			}
+			keep, err := paths.keeps(ctx, name)
+			if err != nil {
+				return err
+			}
+			if keep {
+				kept = append(kept, keptChange{name, change})
+			}
+		}
+
+		if len(kept) > 0 && !opts.Filter.excludes(c.Author, c.Message) {
+			people := creditCommit(c.Author, c.Message, opts.CoAuthors, identities, opts.Filter)
+			for _, k := range kept {
+				for _, person := range people {
+					commit := commitinfo.CommitInfo{
+						Hash:             c.Hash,
+						Author:           person.resolved.Name,
+						Email:            person.resolved.Email,
+						OriginalAuthor:   person.original.Name,
+						OriginalEmail:    person.original.Email,
+						Filename:         k.name,
+						OriginalFilename: k.change.Path,
+						Timestamp:        c.When,
+						Weight:           person.weight,
+						LinesAdded:       k.change.Added,
+						LinesDeleted:     k.change.Deleted,
+					}
+					if !yield(commit, nil) {
+						return errStopWalk
+					}
+				}
+			}
+			count++
+		}
+
+		if opts.MaxCount > 0 && count >= opts.MaxCount {
+			return errStopWalk
+		}
+		return nil
+	}
+
+	// skipped merges are never diffed; commits opts.Filter drops are, so
+	// that it only counts those that change analyzed files
+	pool := newDiffPool(ctx, opts.Workers)
+	err = source.Walk(ctx, func(c Commit, changes ChangesFunc) error {
+		if opts.Merges == MergesSkipped && c.Parents > 1 {
+			return nil
+		}
+		return pool.submit(c, changes, emit)
+	})
+	if err == nil {
+		err = pool.flush(emit)
+	}
+	pool.close()
+
+	if closeErr := source.Close(); err == nil || errors.Is(err, errStopWalk) {
+		err = closeErr
+	}
+	return err
+}
+
+// matchesPaths reports whether name is one of paths or lies below one of
+// them. An empty paths list matches everything.
+func matchesPaths(name string, paths []string) bool {
+	if len(paths) == 0 {
+		return true
+	}
+	for _, p := range paths {
+		p = strings.TrimSuffix(p, "/")
+		if name == p || strings.HasPrefix(name, p+"/") {
+			return true
+		}
+	}
+	return false
+}
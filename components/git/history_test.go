@@ -0,0 +1,164 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryErrors(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := History(ctx, HistoryOptions{RepoPath: t.TempDir()})
+	assert.ErrorIs(t, err, ErrNotRepository)
+
+	_, err = History(ctx, HistoryOptions{RepoPath: filepath.Join(t.TempDir(), "missing")})
+	assert.ErrorIs(t, err, ErrNotRepository)
+
+	empty := t.TempDir()
+	_, err = git.PlainInit(empty, false)
+	require.NoError(t, err)
+	_, err = History(ctx, HistoryOptions{RepoPath: empty})
+	assert.ErrorIs(t, err, ErrEmptyRepository)
+
+	r := newTestRepo(t)
+	r.commit("alice", map[string]string{"a.txt": "a\n"})
+	_, err = History(ctx, HistoryOptions{RepoPath: r.path, Ref: "no-such-branch"})
+	assert.ErrorIs(t, err, ErrUnknownRef)
+
+	_, err = History(ctx, HistoryOptions{
+		RepoPath: filepath.Join(t.TempDir(), "clone"),
+		CloneURL: filepath.Join(t.TempDir(), "no-such-remote"),
+	})
+	assert.ErrorIs(t, err, ErrCloneFailed)
+}
+
+func TestHistoryClone(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("alice", map[string]string{"a.txt": "a\n"})
+
+	commits, err := History(context.Background(), HistoryOptions{
+		RepoPath: filepath.Join(t.TempDir(), "clone"),
+		CloneURL: r.path,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice:a.txt"}, filesByAuthor(commits))
+}
+
+func TestHistoryOptions(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	first := r.commit("alice", map[string]string{"a.txt": "a\n", "docs/readme.md": "r\n"})
+	r.commit("bob", map[string]string{"docs/readme.md": "r\nr\n"})
+	r.commit("carol", map[string]string{"a.txt": "a\na\n"})
+
+	commits, err := History(ctx, HistoryOptions{RepoPath: r.path, Ref: first.String()})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice:a.txt", "alice:docs/readme.md"}, filesByAuthor(commits))
+
+	commits, err = History(ctx, HistoryOptions{RepoPath: r.path, MaxCount: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bob:docs/readme.md", "carol:a.txt"}, filesByAuthor(commits))
+
+	commits, err = History(ctx, HistoryOptions{RepoPath: r.path, Paths: []string{"docs/"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice:docs/readme.md", "bob:docs/readme.md"}, filesByAuthor(commits))
+
+	// commits are an hour apart starting at 13:00
+	commits, err = History(ctx, HistoryOptions{
+		RepoPath: r.path,
+		Since:    time.Date(2024, 1, 1, 13, 30, 0, 0, time.UTC),
+		Until:    time.Date(2024, 1, 1, 14, 30, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bob:docs/readme.md"}, filesByAuthor(commits))
+}
+
+func TestHistoryAsOf(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	r.commit("alice", map[string]string{"a.txt": "a\n"})
+	r.commit("bob", map[string]string{"b.txt": "b\n"})
+	r.commit("carol", map[string]string{".mailmap": "Alice Smith <alice@example.com>\n"})
+
+	for _, backend := range []Backend{BackendGoGit, BackendCLI} {
+		if backend == BackendCLI {
+			requireGitBinary(t)
+		}
+		opts := HistoryOptions{RepoPath: r.path, UseMailmap: true, Backend: backend}
+
+		// the .mailmap carol added later does not apply yet
+		opts.AsOf = time.Date(2024, 1, 1, 14, 30, 0, 0, time.UTC)
+		commits, err := History(ctx, opts)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"alice:a.txt", "bob:b.txt"}, filesByAuthor(commits), backend.String())
+
+		opts.AsOf = time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+		commits, err = History(ctx, opts)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Alice Smith:a.txt", "bob:b.txt", "carol:.mailmap"}, filesByAuthor(commits), backend.String())
+
+		opts.AsOf = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+		_, err = History(ctx, opts)
+		assert.ErrorIs(t, err, ErrEmptyRepository, backend.String())
+	}
+}
+
+func TestHistoryCanceled(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("alice", map[string]string{"a.txt": "a\n"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := History(ctx, HistoryOptions{RepoPath: r.path, Ref: plumbing.HEAD.String()})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestCommitsStream(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	r.commit("alice", map[string]string{"a.txt": "a\n", "b.txt": "b\n"})
+	r.commit("bob", map[string]string{"b.txt": "b\nb\n"})
+
+	// stopping early ends the walk without an error
+	var seen []string
+	for commit, err := range Commits(ctx, HistoryOptions{RepoPath: r.path}) {
+		require.NoError(t, err)
+		seen = append(seen, commit.Author+":"+commit.Filename)
+		if len(seen) == 2 {
+			break
+		}
+	}
+	assert.Equal(t, []string{"bob:b.txt", "alice:a.txt"}, seen)
+
+	// failures are yielded once
+	var errs []error
+	for _, err := range Commits(ctx, HistoryOptions{RepoPath: r.path, Ref: "missing"}) {
+		errs = append(errs, err)
+	}
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], ErrUnknownRef)
+}
+
+func TestHistoryLineStats(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("alice", map[string]string{"a.txt": "1\n2\n3\n"})
+	r.commit("bob", map[string]string{"a.txt": "1\ntwo\n3\n4\n"})
+	r.commit("carol", map[string]string{"a.txt": ""})
+
+	commits, err := History(context.Background(), HistoryOptions{RepoPath: r.path, LineStats: true})
+	require.NoError(t, err)
+
+	var stats []string
+	for _, c := range commits {
+		stats = append(stats, fmt.Sprintf("%s +%d -%d", c.Author, c.LinesAdded, c.LinesDeleted))
+	}
+	assert.Equal(t, []string{"carol +0 -4", "bob +2 -1", "alice +3 -0"}, stats)
+}
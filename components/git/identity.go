@@ -0,0 +1,287 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Identity is an author name and email.
+type Identity struct {
+	Name  string
+	Email string
+}
+
+// mailmapEntry is the proper name and/or email commits are mapped to.
+type mailmapEntry struct {
+	properName  string
+	properEmail string
+}
+
+// update sets the fields other has, as a later mailmap line does.
+func (e *mailmapEntry) update(other mailmapEntry) {
+	if other.properName != "" {
+		e.properName = other.properName
+	}
+	if other.properEmail != "" {
+		e.properEmail = other.properEmail
+	}
+}
+
+// mailmapEmail holds the entries of one commit email: the one applying to
+// any commit name, and those naming a commit name.
+type mailmapEmail struct {
+	mailmapEntry
+	// names are keyed by lower-cased commit name
+	names map[string]mailmapEntry
+}
+
+// Mailmap resolves author identities the way git's .mailmap does. Emails and
+// names are matched case-insensitively.
+type Mailmap struct {
+	// entries are keyed by lower-cased commit email
+	entries map[string]*mailmapEmail
+}
+
+// ParseMailmap reads mailmap lines of the forms
+//
+//	Proper Name <commit@email>
+//	<proper@email> <commit@email>
+//	Proper Name <proper@email> <commit@email>
+//	Proper Name <proper@email> Commit Name <commit@email>
+//
+// Blank lines, lines starting with # and anything after the last > are
+// ignored. As for git, a later line for a commit email overrides the fields
+// earlier ones set, and one for a commit name and email replaces the earlier
+// one.
+func ParseMailmap(r io.Reader) (*Mailmap, error) {
+	m := &Mailmap{entries: make(map[string]*mailmapEmail)}
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		// a # inside a name does not start a comment, one after the emails
+		// is ignored with the rest of the line
+		if i := strings.LastIndex(line, ">"); i >= 0 {
+			line = line[:i+1]
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		names, emails := splitMailmapLine(line)
+		var entry mailmapEntry
+		var commitName, commitEmail string
+		switch len(emails) {
+		case 1:
+			entry.properName = names[0]
+			commitEmail = emails[0]
+		case 2:
+			entry.properName = names[0]
+			entry.properEmail = emails[0]
+			commitName = names[1]
+			commitEmail = emails[1]
+		default:
+			return nil, fmt.Errorf("mailmap line %d: expected one or two <email> entries", lineNumber)
+		}
+		m.add(commitName, commitEmail, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read mailmap: %w", err)
+	}
+	return m, nil
+}
+
+// add applies entry to the commits with commitEmail and, unless it is
+// empty, commitName.
+func (m *Mailmap) add(commitName, commitEmail string, entry mailmapEntry) {
+	key := strings.ToLower(commitEmail)
+	email := m.entries[key]
+	if email == nil {
+		email = &mailmapEmail{}
+		m.entries[key] = email
+	}
+
+	if commitName == "" {
+		email.update(entry)
+		return
+	}
+	if email.names == nil {
+		email.names = make(map[string]mailmapEntry)
+	}
+	// git replaces an earlier entry for the same name outright
+	email.names[strings.ToLower(commitName)] = entry
+}
+
+// splitMailmapLine splits a line into the names before each <email> and the
+// emails themselves.
+func splitMailmapLine(line string) (names, emails []string) {
+	for {
+		open := strings.Index(line, "<")
+		if open < 0 {
+			return names, emails
+		}
+		end := strings.Index(line[open:], ">")
+		if end < 0 {
+			return names, emails
+		}
+		names = append(names, strings.TrimSpace(line[:open]))
+		emails = append(emails, strings.TrimSpace(line[open+1:open+end]))
+		line = line[open+end+1:]
+	}
+}
+
+// Merge adds the entries of other to m, as if its lines followed those of
+// m. other is left unchanged.
+func (m *Mailmap) Merge(other *Mailmap) {
+	for key, email := range other.entries {
+		m.add("", key, email.mailmapEntry)
+		for name, entry := range email.names {
+			m.add(name, key, entry)
+		}
+	}
+}
+
+// Resolve returns the canonical identity of a commit author.
+func (m *Mailmap) Resolve(id Identity) Identity {
+	if m == nil {
+		return id
+	}
+
+	email := m.entries[strings.ToLower(id.Email)]
+	if email == nil {
+		return id
+	}
+	// an entry naming the commit name wins over the one for the email only
+	match, ok := email.names[strings.ToLower(id.Name)]
+	if !ok {
+		match = email.mailmapEntry
+	}
+
+	if match.properName != "" {
+		id.Name = match.properName
+	}
+	if match.properEmail != "" {
+		id.Email = match.properEmail
+	}
+	return id
+}
+
+// identityResolver turns raw commit authors into canonical identities.
+type identityResolver struct {
+	mailmap *Mailmap
+	// byEmail maps lower-cased emails to the name their identities merge
+	// into, or is nil when identities are not merged by email
+	byEmail map[string]string
+}
+
+// newIdentityResolver builds the resolver for opts: the repository's
+// .mailmap, then opts.AliasFile on top of it.
+func newIdentityResolver(ctx context.Context, source HistorySource, opts HistoryOptions) (*identityResolver, error) {
+	resolver := &identityResolver{}
+	if opts.UseMailmap {
+		mailmap, err := repoMailmap(ctx, source)
+		if err != nil {
+			return nil, err
+		}
+		resolver.mailmap = mailmap
+	}
+
+	if opts.AliasFile != "" {
+		file, err := os.Open(opts.AliasFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not open alias file: %w", err)
+		}
+		defer file.Close()
+
+		aliases, err := ParseMailmap(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", opts.AliasFile, err)
+		}
+		if resolver.mailmap == nil {
+			resolver.mailmap = aliases
+		} else {
+			resolver.mailmap.Merge(aliases)
+		}
+	}
+
+	if opts.MergeByEmail {
+		authors, err := source.Authors(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("could not read commit authors: %w", err)
+		}
+		resolver.byEmail = canonicalNames(resolver.mailmap, authors)
+	}
+
+	return resolver, nil
+}
+
+// canonicalNames picks the name every email's identities merge into: the
+// name most commits with that email use after mailmap resolution, the
+// lexically first of equally frequent ones.
+func canonicalNames(mailmap *Mailmap, authors []Identity) map[string]string {
+	counts := make(map[string]map[string]int)
+	for _, author := range authors {
+		id := mailmap.Resolve(author)
+		if id.Email == "" {
+			continue
+		}
+		key := strings.ToLower(id.Email)
+		if counts[key] == nil {
+			counts[key] = make(map[string]int)
+		}
+		counts[key][id.Name]++
+	}
+
+	names := make(map[string]string, len(counts))
+	for key, nameCounts := range counts {
+		best, most := "", 0
+		for name, count := range nameCounts {
+			if count > most || (count == most && name < best) {
+				best, most = name, count
+			}
+		}
+		names[key] = best
+	}
+	return names
+}
+
+// repoMailmap reads .mailmap from the tree the walk starts from. A
+// repository without one yields a nil Mailmap.
+func repoMailmap(ctx context.Context, source HistorySource) (*Mailmap, error) {
+	content, err := source.ReadFile(ctx, ".mailmap")
+	if err != nil || content == nil {
+		return nil, err
+	}
+
+	mailmap, err := ParseMailmap(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf(".mailmap: %w", err)
+	}
+	return mailmap, nil
+}
+
+// resolve returns the canonical identity of a commit author.
+func (r *identityResolver) resolve(id Identity) Identity {
+	id = r.mailmap.Resolve(id)
+
+	if r.byEmail != nil && id.Email != "" {
+		key := strings.ToLower(id.Email)
+		if name, ok := r.byEmail[key]; ok {
+			id.Name = name
+		} else {
+			// only co-authors use this email, the first name seen wins
+			r.byEmail[key] = id.Name
+		}
+	}
+	return id
+}
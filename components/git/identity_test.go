@@ -0,0 +1,154 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMailmapResolve(t *testing.T) {
+	mailmap, err := ParseMailmap(strings.NewReader(`
+# comment
+Jane Doe <jane@example.com>
+<jane@example.com> <jdoe@old.example.com>
+Jane Doe <jane@example.com> Build Bot <shared@example.com>
+Joe Dev <joe@example.com> <shared@example.com> # trailing comment
+C# Dev <csharp@example.com>
+`))
+	require.NoError(t, err)
+
+	cases := []struct{ in, out Identity }{
+		{Identity{"jdoe", "JANE@example.com"}, Identity{"Jane Doe", "JANE@example.com"}},
+		{Identity{"Jane D.", "jdoe@old.example.com"}, Identity{"Jane D.", "jane@example.com"}},
+		{Identity{"build bot", "shared@example.com"}, Identity{"Jane Doe", "jane@example.com"}},
+		{Identity{"someone", "shared@example.com"}, Identity{"Joe Dev", "joe@example.com"}},
+		{Identity{"Other", "other@example.com"}, Identity{"Other", "other@example.com"}},
+		{Identity{"cs", "csharp@example.com"}, Identity{"C# Dev", "csharp@example.com"}},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.out, mailmap.Resolve(c.in), "resolving %v", c.in)
+	}
+
+	var nilMailmap *Mailmap
+	assert.Equal(t, Identity{"a", "b"}, nilMailmap.Resolve(Identity{"a", "b"}))
+
+	_, err = ParseMailmap(strings.NewReader("no email here\n"))
+	assert.Error(t, err)
+}
+
+func TestMailmapCombine(t *testing.T) {
+	// later lines override earlier ones, as git check-mailmap does
+	mailmap, err := ParseMailmap(strings.NewReader(`
+Jane Doe <jane@x>
+<jane@corp> <jane@x>
+Old Name <old@corp> jd <jane@x>
+<jd@corp> jd <jane@x>
+Joe <joe@x>
+Joseph <joe@x>
+`))
+	require.NoError(t, err)
+
+	cases := []struct{ in, out Identity }{
+		{Identity{"jane", "jane@x"}, Identity{"Jane Doe", "jane@corp"}},
+		// entries for a commit name replace each other and do not combine
+		// with the one for the email
+		{Identity{"JD", "jane@x"}, Identity{"JD", "jd@corp"}},
+		{Identity{"joe", "joe@x"}, Identity{"Joseph", "joe@x"}},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.out, mailmap.Resolve(c.in), "resolving %v", c.in)
+	}
+}
+
+func TestMailmapMerge(t *testing.T) {
+	base, err := ParseMailmap(strings.NewReader("Jane <jane@example.com>\nJoe <joe@example.com>\n"))
+	require.NoError(t, err)
+	aliases, err := ParseMailmap(strings.NewReader("<jane@corp.example.com> <jane@example.com>\nJ. Doe <jane@example.com>\nBot <bot@example.com> ci <joe@example.com>\n"))
+	require.NoError(t, err)
+	other, err := ParseMailmap(strings.NewReader("Janet <jane@example.com>\n"))
+	require.NoError(t, err)
+
+	base.Merge(aliases)
+	other.Merge(aliases)
+	// the aliases come after the lines of base
+	assert.Equal(t, Identity{"J. Doe", "jane@corp.example.com"}, base.Resolve(Identity{"j", "jane@example.com"}))
+	assert.Equal(t, Identity{"Joe", "joe@example.com"}, base.Resolve(Identity{"joe", "joe@example.com"}))
+	assert.Equal(t, Identity{"Bot", "bot@example.com"}, base.Resolve(Identity{"CI", "joe@example.com"}))
+
+	assert.Equal(t, Identity{"J. Doe", "jane@corp.example.com"}, other.Resolve(Identity{"j", "jane@example.com"}))
+
+	// merging into one mailmap does not change the aliases or the mailmaps
+	// they were merged into before
+	later, err := ParseMailmap(strings.NewReader("Jim <jane@example.com>\nBob <bob@example.com> ci <joe@example.com>\n"))
+	require.NoError(t, err)
+	other.Merge(later)
+	assert.Equal(t, Identity{"Jim", "jane@corp.example.com"}, other.Resolve(Identity{"j", "jane@example.com"}))
+	for _, m := range []*Mailmap{base, aliases} {
+		assert.Equal(t, "J. Doe", m.Resolve(Identity{"j", "jane@example.com"}).Name)
+		assert.Equal(t, "Bot", m.Resolve(Identity{"ci", "joe@example.com"}).Name)
+	}
+}
+
+func TestHistoryIdentities(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	r.commitAs("Jane Doe", "jane@example.com", "one", map[string]string{"a.txt": "a\n"})
+	r.commitAs("jdoe", "jane@example.com", "two", map[string]string{"a.txt": "a\na\n"})
+	r.commitAs("Jane D.", "jane@home.example.com", "three", map[string]string{"a.txt": "a\na\na\n"})
+	r.commitAs("Joe", "joe@example.com", "four", map[string]string{
+		"a.txt":    "a\na\na\na\n",
+		".mailmap": "Jane Doe <jane@example.com> <jane@home.example.com>\n",
+	})
+
+	names := func(opts HistoryOptions) []string {
+		opts.RepoPath = r.path
+		opts.Paths = []string{"a.txt"}
+		commits, err := History(ctx, opts)
+		require.NoError(t, err)
+		var result []string
+		for _, c := range commits {
+			result = append(result, c.Author+" <"+c.Email+"> was "+c.OriginalAuthor+" <"+c.OriginalEmail+">")
+		}
+		return result
+	}
+
+	assert.Equal(t, []string{
+		"Joe <joe@example.com> was Joe <joe@example.com>",
+		"Jane D. <jane@home.example.com> was Jane D. <jane@home.example.com>",
+		"jdoe <jane@example.com> was jdoe <jane@example.com>",
+		"Jane Doe <jane@example.com> was Jane Doe <jane@example.com>",
+	}, names(HistoryOptions{}))
+
+	assert.Equal(t, []string{
+		"Joe <joe@example.com> was Joe <joe@example.com>",
+		"Jane Doe <jane@example.com> was Jane D. <jane@home.example.com>",
+		"Jane Doe <jane@example.com> was jdoe <jane@example.com>",
+		"Jane Doe <jane@example.com> was Jane Doe <jane@example.com>",
+	}, names(HistoryOptions{UseMailmap: true, MergeByEmail: true}))
+
+	// jane@example.com is "Jane Doe" and "jdoe" once each, the lexically
+	// first wins whichever the walk meets first
+	assert.Equal(t, []string{
+		"Joe <joe@example.com> was Joe <joe@example.com>",
+		"Jane D. <jane@home.example.com> was Jane D. <jane@home.example.com>",
+		"Jane Doe <jane@example.com> was jdoe <jane@example.com>",
+		"Jane Doe <jane@example.com> was Jane Doe <jane@example.com>",
+	}, names(HistoryOptions{MergeByEmail: true}))
+
+	aliasFile := filepath.Join(t.TempDir(), "aliases")
+	require.NoError(t, os.WriteFile(aliasFile, []byte("Joseph <joe@example.com>\n"), 0o644))
+	assert.Equal(t, []string{
+		"Joseph <joe@example.com> was Joe <joe@example.com>",
+		"Jane D. <jane@home.example.com> was Jane D. <jane@home.example.com>",
+		"jdoe <jane@example.com> was jdoe <jane@example.com>",
+		"Jane Doe <jane@example.com> was Jane Doe <jane@example.com>",
+	}, names(HistoryOptions{AliasFile: aliasFile}))
+
+	_, err := History(ctx, HistoryOptions{RepoPath: r.path, AliasFile: filepath.Join(t.TempDir(), "missing")})
+	assert.Error(t, err)
+}
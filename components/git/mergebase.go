@@ -0,0 +1,63 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// MergeBase returns the hash of the best common ancestor of the refs base
+// and head in the repository of opts, like git merge-base. When there are
+// several, the first one go-git finds is returned.
+func MergeBase(ctx context.Context, opts HistoryOptions, base, head string) (string, error) {
+	repo, err := openRepository(ctx, opts)
+	if err != nil {
+		return "", err
+	}
+
+	var commits [2]*object.Commit
+	for i, ref := range []string{base, head} {
+		if ref == "" {
+			return "", fmt.Errorf("%w: empty ref", ErrUnknownRef)
+		}
+		hash, err := resolveStart(repo, ref)
+		if err != nil {
+			return "", err
+		}
+		if commits[i], err = repo.CommitObject(hash); err != nil {
+			return "", fmt.Errorf("could not read commit %s: %w", hash, err)
+		}
+	}
+
+	bases, err := commits[0].MergeBase(commits[1])
+	if err != nil {
+		return "", fmt.Errorf("could not find merge base of %s and %s: %w", base, head, err)
+	}
+	if len(bases) == 0 {
+		return "", fmt.Errorf("%w: %s and %s", ErrNoMergeBase, base, head)
+	}
+	return bases[0].Hash.String(), nil
+}
+
+// CommitTime returns the committer time of the commit ref names in the
+// repository of opts, or ErrUnknownRef if there is no such commit.
+func CommitTime(ctx context.Context, opts HistoryOptions, ref string) (time.Time, error) {
+	repo, err := openRepository(ctx, opts)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if ref == "" {
+		return time.Time{}, fmt.Errorf("%w: empty ref", ErrUnknownRef)
+	}
+	hash, err := resolveStart(repo, ref)
+	if err != nil {
+		return time.Time{}, err
+	}
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not read commit %s: %w", hash, err)
+	}
+	return commit.Committer.When, nil
+}
@@ -0,0 +1,54 @@
+package git
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeBase(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	base := r.commit("alice", map[string]string{"a.txt": "a\n"})
+
+	wt, err := r.repo.Worktree()
+	require.NoError(t, err)
+	require.NoError(t, wt.Checkout(&git.CheckoutOptions{Hash: base, Branch: "refs/heads/feature", Create: true}))
+	r.commit("bob", map[string]string{"b.txt": "b\n"})
+	require.NoError(t, wt.Checkout(&git.CheckoutOptions{Branch: "refs/heads/master"}))
+	r.commit("carol", map[string]string{"a.txt": "a\nmain\n"})
+
+	opts := HistoryOptions{RepoPath: r.path}
+	mergeBase, err := MergeBase(ctx, opts, "master", "feature")
+	require.NoError(t, err)
+	assert.Equal(t, base.String(), mergeBase)
+
+	_, err = MergeBase(ctx, opts, "master", "nope")
+	assert.ErrorIs(t, err, ErrUnknownRef)
+}
+
+func TestCommitTime(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	r.commit("alice", map[string]string{"a.txt": "a\n"})
+	first := r.when
+	hash := r.commit("bob", map[string]string{"a.txt": "b\n"})
+	_, err := r.repo.CreateTag("2024-01-01", hash, nil)
+	require.NoError(t, err)
+
+	opts := HistoryOptions{RepoPath: r.path}
+	for ref, expected := range map[string]time.Time{"HEAD~1": first, "2024-01-01": r.when, hash.String(): r.when} {
+		when, err := CommitTime(ctx, opts, ref)
+		require.NoError(t, err, ref)
+		assert.True(t, expected.Equal(when), ref)
+	}
+
+	for _, ref := range []string{"", "nope", "90d"} {
+		_, err = CommitTime(ctx, opts, ref)
+		assert.ErrorIs(t, err, ErrUnknownRef, ref)
+	}
+}
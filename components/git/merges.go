@@ -0,0 +1,100 @@
+package git
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// MergeMode selects how merge commits are walked and attributed.
+type MergeMode int
+
+const (
+	// MergesResolution walks every commit and credits the merger only for
+	// files that differ from all parents, i.e. conflict resolutions and
+	// other edits made in the merge itself. Their records have no line
+	// stats, which would include the lines the merged branch changed.
+	MergesResolution MergeMode = iota
+	// MergesSkipped walks every commit but ignores merges.
+	MergesSkipped
+	// MergesFirstParent walks the mainline only, following first parents,
+	// and credits the merger for everything the merge brought in.
+	MergesFirstParent
+)
+
+var mergeModeNames = map[MergeMode]string{
+	MergesResolution:  "resolution",
+	MergesSkipped:     "skip",
+	MergesFirstParent: "first-parent",
+}
+
+func (m MergeMode) String() string {
+	if name, ok := mergeModeNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("MergeMode(%d)", int(m))
+}
+
+// ParseMergeMode parses "resolution", "skip" or "first-parent".
+func ParseMergeMode(s string) (MergeMode, error) {
+	for mode, name := range mergeModeNames {
+		if name == s {
+			return mode, nil
+		}
+	}
+	return MergesResolution, fmt.Errorf("unknown merge mode %q, want resolution, skip or first-parent", s)
+}
+
+// firstParentIter walks from a commit along first parents, like
+// git log --first-parent. Commits outside [since, until] are skipped.
+type firstParentIter struct {
+	next  *object.Commit
+	since time.Time
+	until time.Time
+}
+
+func (i *firstParentIter) Next() (*object.Commit, error) {
+	for i.next != nil {
+		c := i.next
+		i.next = nil
+		if c.NumParents() > 0 {
+			parent, err := c.Parent(0)
+			if err != nil {
+				return nil, err
+			}
+			i.next = parent
+		}
+
+		when := c.Committer.When
+		if (!i.since.IsZero() && when.Before(i.since)) || (!i.until.IsZero() && when.After(i.until)) {
+			continue
+		}
+		return c, nil
+	}
+	return nil, io.EOF
+}
+
+func (i *firstParentIter) ForEach(cb func(*object.Commit) error) error {
+	for {
+		c, err := i.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := cb(c); err != nil {
+			if err == storer.ErrStop {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func (i *firstParentIter) Close() {
+	i.next = nil
+}
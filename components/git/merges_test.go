@@ -0,0 +1,78 @@
+package git
+
+import (
+	"context"
+	"testing"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mergeRepo builds a history where bob works on a side branch that carol
+// merges into master, editing a.txt while resolving the merge.
+func mergeRepo(t *testing.T) *testRepo {
+	r := newTestRepo(t)
+	base := r.commit("alice", map[string]string{"a.txt": "a\n", "b.txt": "b\n"})
+
+	wt, err := r.repo.Worktree()
+	require.NoError(t, err)
+	require.NoError(t, wt.Checkout(&git.CheckoutOptions{Hash: base, Branch: "refs/heads/side", Create: true}))
+	side := r.commit("bob", map[string]string{"b.txt": "b\nside\n"})
+
+	require.NoError(t, wt.Checkout(&git.CheckoutOptions{Branch: "refs/heads/master"}))
+	r.commit("alice", map[string]string{"a.txt": "a\nmain\n"})
+	r.commit("carol", map[string]string{"a.txt": "a\nmain\nresolved\n", "b.txt": "b\nside\n"}, side)
+	return r
+}
+
+func TestHistoryMergeModes(t *testing.T) {
+	ctx := context.Background()
+	r := mergeRepo(t)
+
+	files := func(mode MergeMode) []string {
+		commits, err := History(ctx, HistoryOptions{RepoPath: r.path, Merges: mode})
+		require.NoError(t, err)
+		return filesByAuthor(commits)
+	}
+
+	assert.Equal(t, []string{"alice:a.txt", "alice:a.txt", "alice:b.txt", "bob:b.txt", "carol:a.txt"},
+		files(MergesResolution))
+	assert.Equal(t, []string{"alice:a.txt", "alice:a.txt", "alice:b.txt", "bob:b.txt"},
+		files(MergesSkipped))
+	// the mainline never visits bob's commit; carol brought b.txt in
+	assert.Equal(t, []string{"alice:a.txt", "alice:a.txt", "alice:b.txt", "carol:a.txt", "carol:b.txt"},
+		files(MergesFirstParent))
+}
+
+func TestHistoryMergeLineStats(t *testing.T) {
+	ctx := context.Background()
+	r := mergeRepo(t)
+
+	stats := func(mode MergeMode) map[string][2]int {
+		commits, err := History(ctx, HistoryOptions{RepoPath: r.path, Merges: mode, LineStats: true})
+		require.NoError(t, err)
+		result := make(map[string][2]int)
+		for _, c := range commits {
+			if c.Author == "carol" {
+				result[c.Filename] = [2]int{c.LinesAdded, c.LinesDeleted}
+			}
+		}
+		return result
+	}
+
+	// merge records carry no line stats
+	assert.Equal(t, map[string][2]int{"a.txt": {0, 0}}, stats(MergesResolution))
+	// on the mainline, the merge brings in bob's line as well
+	assert.Equal(t, map[string][2]int{"a.txt": {1, 0}, "b.txt": {1, 0}}, stats(MergesFirstParent))
+}
+
+func TestParseMergeMode(t *testing.T) {
+	for _, mode := range []MergeMode{MergesResolution, MergesSkipped, MergesFirstParent} {
+		parsed, err := ParseMergeMode(mode.String())
+		require.NoError(t, err)
+		assert.Equal(t, mode, parsed)
+	}
+	_, err := ParseMergeMode("octopus")
+	assert.Error(t, err)
+}
@@ -0,0 +1,170 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitattributes"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// IgnoreFile lists paths, in .gitignore syntax, that the analysis skips.
+// It is read from the root of the commit the walk starts from.
+const IgnoreFile = ".techdebtignore"
+
+// generatedHeader matches the header of generated files, e.g. the
+// "// Code generated by protoc-gen-go. DO NOT EDIT." line Go tools write.
+var generatedHeader = regexp.MustCompile(`^\W*Code generated .* DO NOT EDIT\.?\W*$`)
+
+// generatedHeaderLines and generatedHeaderSize bound how far into a file
+// generatedHeader is looked for.
+const (
+	generatedHeaderLines = 10
+	generatedHeaderSize  = 4 << 10
+)
+
+// pathFilter decides which files of the history are analyzed.
+type pathFilter struct {
+	include gitignore.Matcher
+	exclude gitignore.Matcher
+	ignored gitignore.Matcher
+
+	// attributes and source are only set with HistoryOptions.ExcludeGenerated
+	attributes gitattributes.Matcher
+	source     HistorySource
+	generated  map[string]bool
+}
+
+func newPathFilter(ctx context.Context, source HistorySource, opts HistoryOptions) (*pathFilter, error) {
+	f := &pathFilter{
+		include: patternMatcher(opts.Include),
+		exclude: patternMatcher(opts.Exclude),
+	}
+
+	if opts.UseIgnoreFile {
+		content, err := source.ReadFile(ctx, IgnoreFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s: %w", IgnoreFile, err)
+		}
+		f.ignored = patternMatcher(ignorePatterns(content))
+	}
+
+	if opts.ExcludeGenerated {
+		content, err := source.ReadFile(ctx, ".gitattributes")
+		if err != nil {
+			return nil, fmt.Errorf("could not read .gitattributes: %w", err)
+		}
+		attributes, err := gitattributes.ReadAttributes(bytes.NewReader(content), nil, true)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse .gitattributes: %w", err)
+		}
+		// go-git lets the first matching line win where git lets the last
+		// one win, so hand it the lines in reverse
+		slices.Reverse(attributes)
+		f.attributes = gitattributes.NewMatcher(attributes)
+		f.source = source
+		f.generated = make(map[string]bool)
+	}
+
+	return f, nil
+}
+
+// patternMatcher compiles patterns in .gitignore syntax, or returns nil for
+// no patterns.
+func patternMatcher(patterns []string) gitignore.Matcher {
+	if len(patterns) == 0 {
+		return nil
+	}
+	parsed := make([]gitignore.Pattern, 0, len(patterns))
+	for _, p := range patterns {
+		parsed = append(parsed, gitignore.ParsePattern(p, nil))
+	}
+	return gitignore.NewMatcher(parsed)
+}
+
+// ignorePatterns returns the patterns of an ignore file, without blank
+// lines and comments.
+func ignorePatterns(content []byte) []string {
+	var patterns []string
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns
+}
+
+// keeps reports whether the file at the slash-separated path name is
+// analyzed.
+func (f *pathFilter) keeps(ctx context.Context, name string) (bool, error) {
+	path := strings.Split(name, "/")
+	if f.include != nil && !f.include.Match(path, false) {
+		return false, nil
+	}
+	if f.exclude != nil && f.exclude.Match(path, false) {
+		return false, nil
+	}
+	if f.ignored != nil && f.ignored.Match(path, false) {
+		return false, nil
+	}
+	if f.attributes == nil {
+		return true, nil
+	}
+
+	generated, err := f.isGenerated(ctx, name, path)
+	return !generated, err
+}
+
+// isGenerated reports whether a file is marked generated or vendored in
+// .gitattributes or starts with a "Code generated" header. Files missing
+// from the start commit only go by their attributes.
+func (f *pathFilter) isGenerated(ctx context.Context, name string, path []string) (bool, error) {
+	if generated, ok := f.generated[name]; ok {
+		return generated, nil
+	}
+
+	generated := false
+	// asking for specific attributes would stop at the first lines that
+	// set them, which are the wrong ones after the reversal
+	attributes, _ := f.attributes.Match(path, nil)
+	for _, name := range []string{"linguist-generated", "linguist-vendored"} {
+		attribute, ok := attributes[name]
+		if ok && (attribute.IsSet() || (attribute.IsValueSet() && attribute.Value() == "true")) {
+			generated = true
+		}
+	}
+
+	// "-linguist-generated" or "linguist-generated=false" overrides the header
+	marked, ok := attributes["linguist-generated"]
+	overridden := ok && (marked.IsUnset() || (marked.IsValueSet() && marked.Value() == "false"))
+
+	if !generated && !overridden {
+		content, err := f.source.ReadHeader(ctx, name, generatedHeaderSize)
+		if err != nil {
+			return false, fmt.Errorf("could not read %s: %w", name, err)
+		}
+		generated = hasGeneratedHeader(content)
+	}
+
+	f.generated[name] = generated
+	return generated, nil
+}
+
+// hasGeneratedHeader looks for a "Code generated ... DO NOT EDIT" line at
+// the top of content.
+func hasGeneratedHeader(content []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for i := 0; i < generatedHeaderLines && scanner.Scan(); i++ {
+		if generatedHeader.Match(scanner.Bytes()) {
+			return true
+		}
+	}
+	return false
+}
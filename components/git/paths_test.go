@@ -0,0 +1,71 @@
+package git
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHasGeneratedHeader(t *testing.T) {
+	assert.True(t, hasGeneratedHeader([]byte("// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage api\n")))
+	assert.True(t, hasGeneratedHeader([]byte("#!/bin/sh\n# Code generated by make. DO NOT EDIT.\n")))
+	assert.True(t, hasGeneratedHeader([]byte("/* Code generated by tool. DO NOT EDIT. */\n")))
+	assert.False(t, hasGeneratedHeader([]byte("package main\n\n// the Code generated here is fine. DO NOT EDIT lightly\n")))
+	assert.False(t, hasGeneratedHeader([]byte(lines(20)+"// Code generated by tool. DO NOT EDIT.\n")))
+	assert.False(t, hasGeneratedHeader(nil))
+}
+
+func TestHistoryPathFilters(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	r.commit("alice", map[string]string{
+		"main.go":          "package main\n",
+		"api/api.pb.go":    "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage api\n",
+		"api/api.go":       "package api\n",
+		"vendor/lib/x.go":  "package lib\n",
+		"gen/out.go":       "package gen\n",
+		"gen/keep.go":      "// Code generated by hand. DO NOT EDIT.\n",
+		"go.sum":           "sum\n",
+		".gitattributes":   "vendor/** linguist-vendored\ngen/** linguist-generated=true\ngen/keep.go -linguist-generated\n",
+		".techdebtignore":  "# lockfiles\ngo.sum\n\n*.pb.go\n*.md\n!docs/manual.md\n",
+		"docs/manual.md":   "manual\n",
+		"docs/api/ref.md":  "ref\n",
+		"cmd/tool/main.go": "package main\n",
+	})
+
+	files := func(opts HistoryOptions) []string {
+		opts.RepoPath = r.path
+		commits, err := History(ctx, opts)
+		require.NoError(t, err)
+		var names []string
+		for _, c := range commits {
+			names = append(names, c.Filename)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	assert.Equal(t, []string{"cmd/tool/main.go", "main.go"},
+		files(HistoryOptions{Include: []string{"*.go"}, Exclude: []string{"api/", "gen", "vendor/"}}))
+	assert.Equal(t, []string{"docs/api/ref.md", "docs/manual.md"},
+		files(HistoryOptions{Include: []string{"docs/**/*.md"}}))
+
+	assert.Equal(t, []string{
+		".gitattributes", ".techdebtignore", "api/api.go", "cmd/tool/main.go",
+		"docs/manual.md", "gen/keep.go", "gen/out.go", "main.go", "vendor/lib/x.go",
+	}, files(HistoryOptions{UseIgnoreFile: true}))
+
+	for _, backend := range []Backend{BackendGoGit, BackendCLI} {
+		if backend == BackendCLI {
+			requireGitBinary(t)
+		}
+		// gen/keep.go opts out of the generated attribute, header or not
+		assert.Equal(t, []string{
+			".gitattributes", ".techdebtignore", "api/api.go", "cmd/tool/main.go", "docs/api/ref.md",
+			"docs/manual.md", "gen/keep.go", "go.sum", "main.go",
+		}, files(HistoryOptions{ExcludeGenerated: true, Backend: backend}), backend.String())
+	}
+}
@@ -0,0 +1,124 @@
+package git
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// pendingDiff is a commit whose changes are being computed by a diffPool.
+type pendingDiff struct {
+	commit  Commit
+	changes ChangesFunc
+	files   []FileChange
+	err     error
+	done    chan struct{}
+}
+
+// diffPool computes the changes of commits on a fixed number of workers and
+// hands the results back in the order the commits were submitted, so that
+// rename tracking and MaxCount see the same sequence as a sequential walk.
+// At most a small window of commits is in flight at any time.
+type diffPool struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	jobs   chan *pendingDiff
+	queue  []*pendingDiff
+	window int
+	wg     sync.WaitGroup
+	// err is the first error of emit, returned by every later call
+	err error
+}
+
+// newDiffPool starts workers goroutines. Zero or less uses one worker per
+// CPU; a single worker diffs inline on the caller's goroutine.
+func newDiffPool(ctx context.Context, workers int) *diffPool {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	p := &diffPool{ctx: ctx, cancel: cancel, window: 2 * workers}
+	if workers == 1 {
+		return p
+	}
+
+	jobs := make(chan *pendingDiff, p.window)
+	p.jobs = jobs
+	p.wg.Add(workers)
+	for range workers {
+		go func() {
+			defer p.wg.Done()
+			for d := range jobs {
+				if err := p.ctx.Err(); err != nil {
+					d.err = err
+				} else {
+					d.files, d.err = d.changes()
+				}
+				close(d.done)
+			}
+		}()
+	}
+	return p
+}
+
+// submit queues the changes of c and passes the oldest commit to emit once
+// the window is full.
+func (p *diffPool) submit(c Commit, changes ChangesFunc, emit func(*pendingDiff) error) error {
+	if p.err != nil {
+		return p.err
+	}
+	d := &pendingDiff{commit: c, changes: changes}
+	if p.jobs == nil {
+		d.files, d.err = changes()
+		p.err = emit(d)
+		return p.err
+	}
+
+	d.done = make(chan struct{})
+	p.jobs <- d
+	p.queue = append(p.queue, d)
+	if len(p.queue) < p.window {
+		return nil
+	}
+	return p.emitOldest(emit)
+}
+
+// flush passes every queued commit to emit, in order.
+func (p *diffPool) flush(emit func(*pendingDiff) error) error {
+	for len(p.queue) > 0 {
+		if err := p.emitOldest(emit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *diffPool) emitOldest(emit func(*pendingDiff) error) error {
+	if p.err != nil {
+		return p.err
+	}
+	if err := p.ctx.Err(); err != nil {
+		return err
+	}
+	d := p.queue[0]
+	select {
+	case <-d.done:
+	case <-p.ctx.Done():
+		return p.ctx.Err()
+	}
+	p.queue = p.queue[1:]
+	p.err = emit(d)
+	return p.err
+}
+
+// close abandons queued commits and waits for running diffs to finish.
+func (p *diffPool) close() {
+	p.cancel()
+	if p.jobs != nil {
+		close(p.jobs)
+		p.jobs = nil
+	}
+	p.wg.Wait()
+	p.queue = nil
+}
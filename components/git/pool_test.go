@@ -0,0 +1,84 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// generatedRepo builds a history of commits that each edit a few of files
+// files spread over several directories.
+func generatedRepo(tb testing.TB, commits, files int) *testRepo {
+	r := newTestRepo(tb)
+	authors := []string{"alice", "bob", "carol", "dave", "erin"}
+	for i := range commits {
+		changes := make(map[string]string)
+		for j := range 5 {
+			file := (i*7 + j*13) % files
+			changes[fmt.Sprintf("dir%d/file%d.go", file%10, file)] = lines(20+i) + fmt.Sprintf("commit %d\n", i)
+		}
+		r.commit(authors[i%len(authors)], changes)
+	}
+	return r
+}
+
+func TestHistoryWorkers(t *testing.T) {
+	ctx := context.Background()
+	r := generatedRepo(t, 40, 30)
+
+	for _, opts := range []HistoryOptions{
+		{DetectRenames: true, LineStats: true},
+		{MaxCount: 7},
+		{Paths: []string{"dir3"}, MaxCount: 3},
+	} {
+		opts.RepoPath = r.path
+		opts.Workers = 1
+		expected := records(t, ctx, opts)
+
+		for _, workers := range []int{0, 2, 8} {
+			opts.Workers = workers
+			// not sorted: the order must not depend on the workers either
+			assert.Equal(t, expected, records(t, ctx, opts), "%d workers", workers)
+		}
+	}
+}
+
+func TestHistoryWorkersCancel(t *testing.T) {
+	r := generatedRepo(t, 10, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const workers = 4
+	seen := make(map[string]bool)
+	var walkErr error
+	for commit, err := range Commits(ctx, HistoryOptions{RepoPath: r.path, Workers: workers}) {
+		if err != nil {
+			walkErr = err
+			break
+		}
+		seen[commit.Hash] = true
+		cancel()
+	}
+	require.Error(t, walkErr)
+	assert.ErrorIs(t, walkErr, ctx.Err())
+	// commits already queued or being diffed may still come through
+	assert.LessOrEqual(t, len(seen), 2*workers+1)
+}
+
+func BenchmarkHistoryWorkers(b *testing.B) {
+	ctx := context.Background()
+	r := generatedRepo(b, 300, 200)
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			opts := HistoryOptions{RepoPath: r.path, DetectRenames: true, LineStats: true, Workers: workers}
+			for range b.N {
+				_, err := History(ctx, opts)
+				require.NoError(b, err)
+			}
+		})
+	}
+}
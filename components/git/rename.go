@@ -0,0 +1,83 @@
+package git
+
+import (
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"techdebt/components/commitinfo"
+)
+
+// DefaultRenameScore is the similarity percentage above which a deleted and
+// an added file are treated as one renamed file.
+const DefaultRenameScore = 60
+
+// renameTracker maps paths from older commits to the path the file has at
+// the start of the walk. It relies on commits being visited newest first,
+// so that a rename is always seen before the commits that used the old path,
+// and the commit creating a path before those of an earlier file that had it.
+type renameTracker struct {
+	current map[string]string
+}
+
+func newRenameTracker() *renameTracker {
+	return &renameTracker{current: make(map[string]string)}
+}
+
+// resolve returns the current path of a file that had path at the commit
+// being visited, and records the rename if the change was one.
+func (r *renameTracker) resolve(change FileChange) string {
+	path := change.Path
+	if current, ok := r.current[path]; ok {
+		path = current
+	}
+	// older commits with this path changed another file
+	if change.Kind == ChangeAdded || change.From != "" {
+		delete(r.current, change.Path)
+	}
+	if change.From != "" {
+		r.current[change.From] = path
+	}
+	return path
+}
+
+// diffTreeOptions builds the go-git diff options for opts, or nil when
+// rename detection is off.
+func diffTreeOptions(opts HistoryOptions) *object.DiffTreeOptions {
+	if !opts.DetectRenames {
+		return nil
+	}
+	score := opts.RenameScore
+	if score == 0 {
+		score = DefaultRenameScore
+	}
+	return &object.DiffTreeOptions{
+		DetectRenames: true,
+		RenameScore:   score,
+	}
+}
+
+// PathLineage lists, for every file, the paths it has had, starting with
+// its current path and going back in time. Build it by adding records in the
+// order Commits yields them.
+type PathLineage map[string][]string
+
+// Add records the path a file had in one commit.
+func (l PathLineage) Add(commit commitinfo.CommitInfo) {
+	paths, exists := l[commit.Filename]
+	if !exists {
+		paths = []string{commit.Filename}
+	}
+	if commit.OriginalFilename != "" && paths[len(paths)-1] != commit.OriginalFilename {
+		paths = append(paths, commit.OriginalFilename)
+	}
+	l[commit.Filename] = paths
+}
+
+// Lineage builds the PathLineage of records in the order History returns
+// them.
+func Lineage(commits []commitinfo.CommitInfo) PathLineage {
+	lineage := make(PathLineage)
+	for _, commit := range commits {
+		lineage.Add(commit)
+	}
+	return lineage
+}
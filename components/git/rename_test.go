@@ -0,0 +1,68 @@
+package git
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lines returns n numbered lines of text, enough for similarity detection.
+func lines(n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		b.WriteString("line ")
+		b.WriteString(strings.Repeat("x", i))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func TestHistoryDetectRenames(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	r.commit("alice", map[string]string{"old/a.go": lines(20)})
+	r.commit("bob", map[string]string{"old/a.go": lines(21)})
+	r.commit("carol", map[string]string{"old/a.go": "", "mid/a.go": lines(21)})
+	r.commit("dave", map[string]string{"mid/a.go": "", "new/a.go": lines(22)})
+
+	commits, err := History(ctx, HistoryOptions{RepoPath: r.path})
+	require.NoError(t, err)
+	expected := []string{
+		"alice:old/a.go", "bob:old/a.go",
+		"carol:mid/a.go", "carol:old/a.go",
+		"dave:mid/a.go", "dave:new/a.go",
+	}
+	assert.Equal(t, expected, filesByAuthor(commits))
+
+	commits, err = History(ctx, HistoryOptions{RepoPath: r.path, DetectRenames: true})
+	require.NoError(t, err)
+	expected = []string{"alice:new/a.go", "bob:new/a.go", "carol:new/a.go", "dave:new/a.go"}
+	assert.Equal(t, expected, filesByAuthor(commits))
+
+	lineage := Lineage(commits)
+	assert.Equal(t, PathLineage{"new/a.go": {"new/a.go", "mid/a.go", "old/a.go"}}, lineage)
+
+	// path filters apply to the current path
+	commits, err = History(ctx, HistoryOptions{RepoPath: r.path, DetectRenames: true, Paths: []string{"new"}})
+	require.NoError(t, err)
+	assert.Len(t, commits, 4)
+}
+
+func TestHistoryRenameScore(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	r.commit("alice", map[string]string{"a.go": lines(10)})
+	// moved and half rewritten
+	r.commit("bob", map[string]string{"a.go": "", "b.go": lines(5) + strings.Repeat("other\n", 5)})
+
+	commits, err := History(ctx, HistoryOptions{RepoPath: r.path, DetectRenames: true, RenameScore: 90})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice:a.go", "bob:a.go", "bob:b.go"}, filesByAuthor(commits))
+
+	commits, err = History(ctx, HistoryOptions{RepoPath: r.path, DetectRenames: true, RenameScore: 30})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice:b.go", "bob:b.go"}, filesByAuthor(commits))
+}
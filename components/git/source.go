@@ -0,0 +1,103 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Commit is a commit as read by a HistorySource.
+type Commit struct {
+	Hash    string
+	Author  Identity
+	When    time.Time
+	Message string
+	Parents int
+}
+
+// ChangesFunc returns the files a commit changed. Sources may compute them
+// lazily, so commits that are filtered out are never diffed.
+type ChangesFunc func() ([]FileChange, error)
+
+// HistorySource reads the history of one repository. Every source must
+// produce the same commits and changes for the same options, so that the
+// records History builds from them do not depend on the backend.
+type HistorySource interface {
+	// Walk calls visit for every commit selected by the options the source
+	// was opened with, newest first by committer date. Merge commits are
+	// diffed according to the options' MergeMode: against their first parent
+	// for MergesFirstParent, otherwise keeping only the files that differ
+	// from every parent. Walk stops and returns the error if visit fails.
+	Walk(ctx context.Context, visit func(Commit, ChangesFunc) error) error
+	// Authors returns the author of every commit Walk visits, without
+	// diffing any of them.
+	Authors(ctx context.Context) ([]Identity, error)
+	// ReadFile returns the content of a file in the tree of the commit the
+	// walk starts from, or nil if there is no such file.
+	ReadFile(ctx context.Context, name string) ([]byte, error)
+	// ReadHeader returns at most the first size bytes of a file ReadFile
+	// would return, or nil if there is no such file. It is meant to be called
+	// for many files in turn.
+	ReadHeader(ctx context.Context, name string, size int) ([]byte, error)
+	// Close releases the source once no ChangesFunc is running anymore.
+	Close() error
+}
+
+// Backend selects the HistorySource implementation.
+type Backend int
+
+const (
+	// BackendGoGit reads repositories in-process with go-git.
+	BackendGoGit Backend = iota
+	// BackendCLI runs the local git binary and parses its output, which is
+	// much faster on large repositories.
+	BackendCLI
+)
+
+var backendNames = map[Backend]string{
+	BackendGoGit: "go-git",
+	BackendCLI:   "git",
+}
+
+func (b Backend) String() string {
+	if name, ok := backendNames[b]; ok {
+		return name
+	}
+	return fmt.Sprintf("Backend(%d)", int(b))
+}
+
+// ParseBackend parses "go-git" or "git".
+func ParseBackend(s string) (Backend, error) {
+	for backend, name := range backendNames {
+		if name == s {
+			return backend, nil
+		}
+	}
+	return BackendGoGit, fmt.Errorf("unknown backend %q, want go-git or git", s)
+}
+
+// OpenSource opens the repository of opts with the backend opts selects.
+func OpenSource(ctx context.Context, opts HistoryOptions) (HistorySource, error) {
+	// return an untyped nil on failure, a nil *goGitSource would not be
+	// nil as a HistorySource
+	switch opts.Backend {
+	case BackendGoGit:
+		source, err := newGoGitSource(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		return source, nil
+	case BackendCLI:
+		source, err := newCLISource(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		return source, nil
+	}
+	return nil, fmt.Errorf("unknown backend %v", opts.Backend)
+}
+
+// errStopWalk is returned by visit functions to end a walk early without
+// an error.
+var errStopWalk = errors.New("stop walk")
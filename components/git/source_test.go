@@ -0,0 +1,177 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func requireGitBinary(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary not available")
+	}
+}
+
+// records renders commits with every field, so backends can be compared
+// exactly. Timestamps are compared in seconds, the precision of git.
+func records(t *testing.T, ctx context.Context, opts HistoryOptions) []string {
+	t.Helper()
+	commits, err := History(ctx, opts)
+	require.NoError(t, err)
+	var result []string
+	for _, c := range commits {
+		result = append(result, fmt.Sprintf("%.7s %s <%s> (%s <%s>) %s<-%s %d %.2f +%d -%d",
+			c.Hash, c.Author, c.Email, c.OriginalAuthor, c.OriginalEmail, c.Filename, c.OriginalFilename,
+			c.Timestamp.Unix(), c.Weight, c.LinesAdded, c.LinesDeleted))
+	}
+	return result
+}
+
+// authors returns the commit authors of the source opts selects.
+func authors(t *testing.T, ctx context.Context, opts HistoryOptions) []Identity {
+	t.Helper()
+	source, err := OpenSource(ctx, opts)
+	require.NoError(t, err)
+	defer source.Close()
+	authors, err := source.Authors(ctx)
+	require.NoError(t, err)
+	return authors
+}
+
+func TestBackendParity(t *testing.T) {
+	requireGitBinary(t)
+	ctx := context.Background()
+
+	renames := newTestRepo(t)
+	renames.commit("alice", map[string]string{"old/a.go": lines(20), "b.bin": "\x00\x01"})
+	renames.commit("bob", map[string]string{"old/a.go": lines(21)})
+	renames.commitAs("carol", "carol@example.com", "move\n\nCo-authored-by: dave <dave@example.com>\n",
+		map[string]string{"old/a.go": "", "new/a.go": lines(22), ".mailmap": "Bob B <bob@example.com>\n"})
+
+	// separators and numstat lookalikes in names and messages do not
+	// confuse the log parser
+	separators := newTestRepo(t)
+	separators.commitAs("eve\x1e\x1fx", "eve@example.com", "odd\x1e\n\n1\t2\tfake.go\x1f\n",
+		map[string]string{"a.txt": "a\n"})
+	separators.commitAs("frank", "frank@example.com", "\x1f", map[string]string{"a.txt": "b\n", "b.txt": "b\n"})
+
+	fixtures := map[string]*testRepo{
+		"merges":     mergeRepo(t),
+		"renames":    renames,
+		"separators": separators,
+	}
+	variants := map[string]HistoryOptions{
+		"default":      {},
+		"renames":      {DetectRenames: true, RenameScore: 50},
+		"lines":        {DetectRenames: true, LineStats: true},
+		"skip merges":  {Merges: MergesSkipped, LineStats: true},
+		"first parent": {Merges: MergesFirstParent, LineStats: true},
+		"coauthors":    {CoAuthors: CoAuthorsSplit, UseMailmap: true},
+		"max count":    {MaxCount: 2},
+		"paths":        {Paths: []string{"a.txt", "new"}, DetectRenames: true},
+		"ref":          {Ref: "HEAD~1"},
+	}
+
+	for fixture, r := range fixtures {
+		for variant, opts := range variants {
+			t.Run(fixture+"/"+variant, func(t *testing.T) {
+				opts.RepoPath = r.path
+				opts.Backend = BackendGoGit
+				expected := records(t, ctx, opts)
+				require.NotEmpty(t, expected)
+
+				expectedAuthors := authors(t, ctx, opts)
+
+				opts.Backend = BackendCLI
+				assert.Equal(t, expected, records(t, ctx, opts))
+				assert.Equal(t, expectedAuthors, authors(t, ctx, opts))
+			})
+		}
+	}
+}
+
+func TestCLISourceErrors(t *testing.T) {
+	requireGitBinary(t)
+	ctx := context.Background()
+	r := newTestRepo(t)
+
+	_, err := OpenSource(ctx, HistoryOptions{RepoPath: r.path, Backend: BackendCLI})
+	assert.ErrorIs(t, err, ErrEmptyRepository)
+
+	r.commit("alice", map[string]string{"dir/a.txt": "a\n"})
+	_, err = OpenSource(ctx, HistoryOptions{RepoPath: r.path, Backend: BackendCLI, Ref: "nope"})
+	assert.ErrorIs(t, err, ErrUnknownRef)
+
+	// a directory inside a work tree is not a repository, as for go-git
+	_, err = OpenSource(ctx, HistoryOptions{RepoPath: filepath.Join(r.path, "dir"), Backend: BackendCLI})
+	assert.ErrorIs(t, err, ErrNotRepository)
+	_, err = OpenSource(ctx, HistoryOptions{RepoPath: t.TempDir(), Backend: BackendCLI})
+	assert.ErrorIs(t, err, ErrNotRepository)
+}
+
+func TestCLISourceReadFile(t *testing.T) {
+	requireGitBinary(t)
+	ctx := context.Background()
+	r := newTestRepo(t)
+	r.commit("alice", map[string]string{".mailmap": "Alice <alice@example.com>\n", "dir/a.txt": "a\n"})
+
+	source, err := OpenSource(ctx, HistoryOptions{RepoPath: r.path, Backend: BackendCLI})
+	require.NoError(t, err)
+
+	data, err := source.ReadFile(ctx, ".mailmap")
+	require.NoError(t, err)
+	assert.Equal(t, "Alice <alice@example.com>\n", string(data))
+
+	for _, missing := range []string{"missing", "dir"} {
+		data, err = source.ReadFile(ctx, missing)
+		require.NoError(t, err)
+		assert.Nil(t, data, missing)
+	}
+}
+
+func TestReadHeader(t *testing.T) {
+	requireGitBinary(t)
+	ctx := context.Background()
+	r := newTestRepo(t)
+	r.commit("alice", map[string]string{"big.txt": lines(2000), "dir/a b.txt": "a\n", "line\nbreak.txt": lines(3)})
+
+	for _, backend := range []Backend{BackendGoGit, BackendCLI} {
+		t.Run(backend.String(), func(t *testing.T) {
+			source, err := OpenSource(ctx, HistoryOptions{RepoPath: r.path, Backend: backend})
+			require.NoError(t, err)
+			defer source.Close()
+
+			// repeated reads go through the same batch on the CLI backend
+			for range 2 {
+				for _, name := range []string{"big.txt", "dir/a b.txt", "line\nbreak.txt"} {
+					content, err := source.ReadFile(ctx, name)
+					require.NoError(t, err)
+					header, err := source.ReadHeader(ctx, name, 100)
+					require.NoError(t, err)
+					assert.Equal(t, content[:min(100, len(content))], header, name)
+				}
+				for _, missing := range []string{"missing", "dir", "dir/missing b"} {
+					header, err := source.ReadHeader(ctx, missing, 100)
+					require.NoError(t, err)
+					assert.Nil(t, header, missing)
+				}
+			}
+		})
+	}
+}
+
+func TestParseBackend(t *testing.T) {
+	for _, backend := range []Backend{BackendGoGit, BackendCLI} {
+		parsed, err := ParseBackend(backend.String())
+		require.NoError(t, err)
+		assert.Equal(t, backend, parsed)
+	}
+	_, err := ParseBackend("libgit2")
+	assert.Error(t, err)
+}
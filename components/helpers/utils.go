@@ -1,5 +1,10 @@
 package helpers
 
+import (
+	"maps"
+	"slices"
+)
+
 type TypeProb float32
 
 // Contains checks if a slice contains a specific string.
@@ -12,39 +17,55 @@ func Contains(slice []string, item string) bool {
 	return false
 }
 
-func transformOccurancesToCounts(data []int) []int {
-	/*
-		transforms [0 1 1 3] into [1 2 0 1]
-		this works for dense data
-		TODO: implement sparse data solution using map[int]int
-	*/
-	if len(data) == 0 {
-		return data
-	}
-
-	var maxElem int
-	var counts []int
-
-	maxElem = data[0]
-	for _, obj := range data {
-		maxElem = max(obj, maxElem)
-	}
+// Number is a count or a weight.
+type Number interface {
+	~int | ~int32 | ~int64 | ~uint | ~uint32 | ~uint64 | ~float32 | ~float64
+}
 
-	counts = make([]int, maxElem+1)
+// Counts counts how often each value occurs in data, e.g. how many commits
+// each author made from a list of commit authors.
+//
+//	[alice bob bob] -> {alice: 1, bob: 2}
+func Counts[K comparable](data []K) map[K]int {
+	counts := make(map[K]int)
 	for _, obj := range data {
 		counts[obj]++
 	}
 	return counts
 }
 
+// Probabilities turns counts or weights into the share of their total each
+// key has. All shares are 0 if the total is.
+//
+//	{alice: 1, bob: 3} -> {alice: 0.25, bob: 0.75}
+func Probabilities[K comparable, N Number](counts map[K]N) map[K]float64 {
+	var total float64
+	for _, count := range counts {
+		total += float64(count)
+	}
+
+	probs := make(map[K]float64, len(counts))
+	for key, count := range counts {
+		if total == 0 {
+			probs[key] = 0
+			continue
+		}
+		probs[key] = float64(count) / total
+	}
+	return probs
+}
+
 func MakeProbabilitiesFromOccurances(data []int) []TypeProb {
 	// data is occurances, eg [0 1 1 3 0 2 2 1 0]
-	// where 0,1,2,3 are observation indices
-	var counts []int
-	var probs []TypeProb
+	// where 0,1,2,3 are observation indices; the probabilities of the
+	// observed indices are returned in ascending order of index
+	counts := Counts(data)
+	indices := slices.Sorted(maps.Keys(counts))
 
-	counts = transformOccurancesToCounts(data)
-	probs = MakeProbabilitiesFromCounts(counts)
+	probs := make([]TypeProb, len(indices))
+	for i, index := range indices {
+		probs[i] = TypeProb(counts[index]) / TypeProb(len(data))
+	}
 	return probs
 }
 
@@ -64,3 +85,24 @@ func MakeProbabilitiesFromCounts(data []int) []TypeProb {
 
 	return probs
 }
+
+func MakeProbabilitiesFromWeights(data []float64) []TypeProb {
+	// data is weights, eg [1.5 0.5 2]
+	// where 1.5,0.5,2 are the (possibly fractional) totals for each index
+	var total float64 = 0
+	var probs []TypeProb = make([]TypeProb, len(data))
+
+	for _, weight := range data {
+		total = total + weight
+	}
+	if total == 0 {
+		// nothing was observed, e.g. only binary files changed
+		return probs
+	}
+
+	for i, w := range data {
+		probs[i] = TypeProb(w / total)
+	}
+
+	return probs
+}
@@ -35,32 +35,28 @@ func TestEntropyFromOccurances(t *testing.T) {
 	expected = []TypeProb{2.0 / 4.0, 1.0 / 4.0, 1.0 / 4.0}
 	assert.Equal(t, expected, actual, "should be equal")
 
-}
-
-func TestTransformOccurancesToCounts(t *testing.T) {
-	var data []int
-	var actual, expected []int
-
-	data = []int{}
-	actual = transformOccurancesToCounts(data)
-	expected = []int{}
-	assert.Equal(t, expected, actual, "should be equal")
-
-	data = []int{0}
-	actual = transformOccurancesToCounts(data)
-	expected = []int{1}
+	data = []int{1 << 40, -3, -3, 1 << 40}
+	actual = MakeProbabilitiesFromOccurances(data)
+	expected = []TypeProb{0.5, 0.5}
 	assert.Equal(t, expected, actual, "should be equal")
 
-	data = []int{1, 1, 3}
-	actual = transformOccurancesToCounts(data)
-	expected = []int{0, 2, 0, 1}
-	assert.Equal(t, expected, actual, "should be equal")
+}
 
-	data = []int{1, 2, 3}
-	actual = transformOccurancesToCounts(data)
-	expected = []int{0, 1, 1, 1}
-	assert.Equal(t, expected, actual, "should be equal")
+func TestCounts(t *testing.T) {
+	assert.Equal(t, map[int]int{}, Counts([]int{}))
+	assert.Equal(t, map[int]int{1: 2, 3: 1}, Counts([]int{1, 1, 3}))
+	// sparse, large and negative values only take an entry each
+	assert.Equal(t, map[int]int{-5: 1, 1 << 40: 2}, Counts([]int{1 << 40, -5, 1 << 40}))
+	assert.Equal(t, map[string]int{"alice": 1, "bob": 2}, Counts([]string{"bob", "alice", "bob"}))
+}
 
+func TestProbabilities(t *testing.T) {
+	assert.Equal(t, map[string]float64{}, Probabilities(map[string]int{}))
+	assert.Equal(t, map[string]float64{"alice": 0.25, "bob": 0.75},
+		Probabilities(map[string]int{"alice": 1, "bob": 3}))
+	assert.Equal(t, map[string]float64{"alice": 0.25, "bob": 0.75},
+		Probabilities(map[string]float64{"alice": 0.5, "bob": 1.5}))
+	assert.Equal(t, map[int]float64{1: 0, 2: 0}, Probabilities[int](map[int]float64{1: 0, 2: 0}))
 }
 
 func TestEntropyFromCounts(t *testing.T) {
@@ -93,3 +89,24 @@ func TestEntropyFromCounts(t *testing.T) {
 	assert.Equal(t, expected, actual, "should be equal")
 
 }
+
+func TestProbabilitiesFromWeights(t *testing.T) {
+	var data []float64
+	var actual, expected []TypeProb
+
+	data = []float64{}
+	actual = MakeProbabilitiesFromWeights(data)
+	expected = []TypeProb{}
+	assert.Equal(t, expected, actual, "should be equal")
+
+	data = []float64{0, 0}
+	actual = MakeProbabilitiesFromWeights(data)
+	expected = []TypeProb{0, 0}
+	assert.Equal(t, expected, actual, "should be equal")
+
+	data = []float64{0.5, 0.5, 1}
+	actual = MakeProbabilitiesFromWeights(data)
+	expected = []TypeProb{1.0 / 4.0, 1.0 / 4.0, 2.0 / 4.0}
+	assert.Equal(t, expected, actual, "should be equal")
+
+}
@@ -0,0 +1,108 @@
+package workspace
+
+import (
+	"sort"
+
+	"techdebt/components/entropy"
+)
+
+// AuthorConcentration tracks how much of a workspace depends on one author.
+type AuthorConcentration struct {
+	Author string
+	// Weight is the author's share of all changes in the workspace.
+	Weight float64
+	// Repos is the number of repositories the author changed.
+	Repos int
+	// DominantRepos lists the repositories where the author made more than
+	// half of the changes.
+	DominantRepos []string
+	// SoleFiles counts the files only this author ever changed.
+	SoleFiles int
+}
+
+// Pool pools the contributions of the repositories of a workspace, treating
+// the same person in several repositories as one.
+type Pool struct {
+	authors       map[string]float64
+	concentration map[string]*AuthorConcentration
+}
+
+func NewPool() *Pool {
+	return &Pool{
+		authors:       make(map[string]float64),
+		concentration: make(map[string]*AuthorConcentration),
+	}
+}
+
+// Add pools the weight of each author's contributions to each file of the
+// repository named repo.
+//
+//	{
+//	 filename1: {author1: 1, author2: 6},
+//	 filename2: {author0: 5, author1: 3}
+//	}
+func (p *Pool) Add(repo string, fileAuthors map[string]map[string]float64) {
+	authors := make(map[string]float64)
+	var total float64
+	for _, authorWeights := range fileAuthors {
+		for author, weight := range authorWeights {
+			authors[author] += weight
+			total += weight
+		}
+	}
+
+	for author, weight := range authors {
+		p.authors[author] += weight
+		c := p.author(author)
+		c.Repos++
+		if total > 0 && weight/total > 0.5 {
+			c.DominantRepos = append(c.DominantRepos, repo)
+		}
+	}
+	for _, authorWeights := range fileAuthors {
+		if len(authorWeights) == 1 {
+			for author := range authorWeights {
+				p.author(author).SoleFiles++
+			}
+		}
+	}
+}
+
+func (p *Pool) author(name string) *AuthorConcentration {
+	c := p.concentration[name]
+	if c == nil {
+		c = &AuthorConcentration{Author: name}
+		p.concentration[name] = c
+	}
+	return c
+}
+
+// Authors returns the concentration of every author, biggest share of the
+// workspace first, then by name.
+func (p *Pool) Authors() []AuthorConcentration {
+	var total float64
+	for _, weight := range p.authors {
+		total += weight
+	}
+
+	authors := make([]AuthorConcentration, 0, len(p.concentration))
+	for author, c := range p.concentration {
+		if total > 0 {
+			c.Weight = p.authors[author] / total
+		}
+		authors = append(authors, *c)
+	}
+	sort.Slice(authors, func(i, j int) bool {
+		if authors[i].Weight != authors[j].Weight {
+			return authors[i].Weight > authors[j].Weight
+		}
+		return authors[i].Author < authors[j].Author
+	})
+	return authors
+}
+
+// AuthorEntropy is the entropy of the pooled author weights of all
+// repositories.
+func (p *Pool) AuthorEntropy() float64 {
+	return entropy.EntropyOfCounts(p.authors)
+}
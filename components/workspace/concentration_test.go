@@ -0,0 +1,42 @@
+package workspace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPool(t *testing.T) {
+	pool := NewPool()
+	pool.Add("api", map[string]map[string]float64{
+		"main.go": {"alice": 3, "bob": 1},
+		"db.go":   {"alice": 2},
+	})
+	pool.Add("web", map[string]map[string]float64{
+		"index.js": {"bob": 2, "carol": 1},
+		"app.js":   {"carol": 1},
+	})
+
+	assert.Equal(t, []AuthorConcentration{
+		// 5 of 10 changes, 5 of 6 in api
+		{Author: "alice", Weight: 0.5, Repos: 1, DominantRepos: []string{"api"}, SoleFiles: 1},
+		{Author: "bob", Weight: 0.3, Repos: 2},
+		// carol has exactly half of web, which is not dominant
+		{Author: "carol", Weight: 0.2, Repos: 1, SoleFiles: 1},
+	}, pool.Authors())
+
+	// alice 5, bob 3, carol 2
+	assert.InDelta(t, 1.4855, pool.AuthorEntropy(), 1e-4)
+}
+
+func TestPoolTies(t *testing.T) {
+	pool := NewPool()
+	pool.Add("api", map[string]map[string]float64{"main.go": {"bob": 1, "alice": 1}})
+
+	authors := pool.Authors()
+	assert.Equal(t, "alice", authors[0].Author)
+	assert.Equal(t, "bob", authors[1].Author)
+	assert.InDelta(t, 1, pool.AuthorEntropy(), 1e-6)
+
+	assert.Empty(t, NewPool().Authors())
+}
@@ -0,0 +1,66 @@
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Repo is one repository of a workspace: a local clone or bare mirror.
+type Repo struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// Manifest lists the repositories analyzed together as one workspace.
+//
+//	{
+//	 "repos": [
+//	  {"name": "api", "path": "../api"},
+//	  {"path": "/srv/mirrors/web.git"}
+//	 ]
+//	}
+type Manifest struct {
+	Repos []Repo `json:"repos"`
+}
+
+// ReadManifest reads a JSON manifest. Relative repository paths are resolved
+// against the manifest's directory, and repositories without a name are
+// named after their path.
+func ReadManifest(filename string) (Manifest, error) {
+	var manifest Manifest
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return manifest, fmt.Errorf("could not read manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return manifest, fmt.Errorf("could not decode manifest %s: %w", filename, err)
+	}
+	if len(manifest.Repos) == 0 {
+		return manifest, fmt.Errorf("manifest %s lists no repos", filename)
+	}
+
+	dir := filepath.Dir(filename)
+	seen := make(map[string]bool)
+	for i, repo := range manifest.Repos {
+		if repo.Path == "" {
+			return manifest, fmt.Errorf("manifest %s: repo %d has no path", filename, i+1)
+		}
+		if !filepath.IsAbs(repo.Path) {
+			repo.Path = filepath.Join(dir, repo.Path)
+		}
+		if repo.Name == "" {
+			repo.Name = strings.TrimSuffix(filepath.Base(repo.Path), ".git")
+		}
+		if seen[repo.Name] {
+			return manifest, fmt.Errorf("manifest %s: repo name %q is used twice", filename, repo.Name)
+		}
+		seen[repo.Name] = true
+		manifest.Repos[i] = repo
+	}
+
+	return manifest, nil
+}
@@ -0,0 +1,48 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeManifest(t *testing.T, content string) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "workspace.json")
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0o644))
+	return filename
+}
+
+func TestReadManifest(t *testing.T) {
+	filename := writeManifest(t, `{"repos": [
+		{"name": "api", "path": "../api"},
+		{"path": "/srv/mirrors/web.git"}
+	]}`)
+	dir := filepath.Dir(filename)
+
+	manifest, err := ReadManifest(filename)
+	require.NoError(t, err)
+	expected := Manifest{Repos: []Repo{
+		{Name: "api", Path: filepath.Join(dir, "../api")},
+		{Name: "web", Path: "/srv/mirrors/web.git"},
+	}}
+	assert.Equal(t, expected, manifest)
+}
+
+func TestReadManifestErrors(t *testing.T) {
+	for _, content := range []string{
+		`not json`,
+		`{"repos": []}`,
+		`{"repos": [{"name": "api"}]}`,
+		`{"repos": [{"path": "a/api"}, {"path": "b/api"}]}`,
+	} {
+		_, err := ReadManifest(writeManifest(t, content))
+		assert.Error(t, err, content)
+	}
+
+	_, err := ReadManifest(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
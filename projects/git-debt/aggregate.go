@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"iter"
+
+	"techdebt/components/commitinfo"
+	"techdebt/components/entropy"
+	"techdebt/components/git"
+)
+
+// accumulator builds up an aggregate one CommitInfo at a time, so history
+// can be analyzed while it is being streamed.
+type accumulator interface {
+	Add(commit commitinfo.CommitInfo)
+}
+
+// consume feeds every record of a commit stream to the accumulators and
+// returns the first error of the stream.
+func consume(commits iter.Seq2[commitinfo.CommitInfo, error], accumulators ...accumulator) error {
+	for commit, err := range commits {
+		if err != nil {
+			return err
+		}
+		for _, acc := range accumulators {
+			acc.Add(commit)
+		}
+	}
+	return nil
+}
+
+// history streams the records of opts, weighted by lines changed when
+// byLines is set.
+func history(ctx context.Context, opts git.HistoryOptions, byLines bool) iter.Seq2[commitinfo.CommitInfo, error] {
+	commits := git.Commits(ctx, opts)
+	if byLines {
+		commits = weightByLines(commits)
+	}
+	return commits
+}
+
+// weightByLines scales the weight of every record by the lines it changed,
+// so that aggregates measure churn instead of commit counts.
+func weightByLines(commits iter.Seq2[commitinfo.CommitInfo, error]) iter.Seq2[commitinfo.CommitInfo, error] {
+	return func(yield func(commitinfo.CommitInfo, error) bool) {
+		for commit, err := range commits {
+			commit.Weight *= float64(commit.LinesAdded + commit.LinesDeleted)
+			if !yield(commit, err) {
+				return
+			}
+		}
+	}
+}
+
+// decayed passes records on to its accumulators with their weight decayed
+// by age, so that raw and decayed aggregates can be built in one pass.
+type decayed struct {
+	decay        entropy.Decay
+	accumulators []accumulator
+}
+
+func (d decayed) Add(commit commitinfo.CommitInfo) {
+	commit.Weight *= d.decay.Factor(commit.Timestamp)
+	for _, acc := range d.accumulators {
+		acc.Add(commit)
+	}
+}
+
+// fileAuthorCounts counts, per file, how many commits each author made to
+// it. Commits shared with co-authors may count fractionally.
+//
+//	{
+//	 filename1: {author1: 1, author2: 6},
+//	 filename2: {author0: 5, author1: 3}
+//	}
+type fileAuthorCounts map[string]map[string]float64
+
+func (a fileAuthorCounts) Add(commit commitinfo.CommitInfo) {
+	// Check if the filename already exists in the map
+	if _, exists := a[commit.Filename]; !exists {
+		a[commit.Filename] = make(map[string]float64)
+	}
+	a[commit.Filename][commit.Author] += commit.Weight
+}
+
+// authorCounts counts the file changes made by each author.
+//
+//	{
+//	 author0: 5,
+//	 author1: 1, ...
+//	}
+type authorCounts map[string]float64
+
+func (a authorCounts) Add(commit commitinfo.CommitInfo) {
+	a[commit.Author] += commit.Weight
+}
+
+// fileCounts counts the changes made to each file.
+//
+//	{
+//	 filename0: 5,
+//	 filename1: 1, ...
+//	}
+type fileCounts map[string]float64
+
+func (a fileCounts) Add(commit commitinfo.CommitInfo) {
+	a[commit.Filename] += commit.Weight
+}
+
+// records keeps every record, for analyses that need the whole history
+// in time order.
+type records []commitinfo.CommitInfo
+
+func (r *records) Add(commit commitinfo.CommitInfo) {
+	*r = append(*r, commit)
+}
+
+// fileNames maps the path a file had in a commit to the name the history
+// tracks it under, so that a file renamed later can be matched across two
+// histories of the same commits.
+type fileNames map[commitPath]string
+
+// commitPath is a path as of one commit.
+type commitPath struct {
+	Hash, Path string
+}
+
+func (n fileNames) Add(commit commitinfo.CommitInfo) {
+	n[commitPath{Hash: commit.Hash, Path: commit.OriginalFilename}] = commit.Filename
+}
+
+func transformMapCountsToArray(countmap map[string]float64) []float64 {
+	var res []float64
+	var i int
+
+	res = make([]float64, len(countmap))
+	i = 0
+	for _, count := range countmap {
+		res[i] = count
+		i++
+	}
+
+	return res
+}
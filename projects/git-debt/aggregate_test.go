@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"techdebt/components/commitinfo"
+	"techdebt/components/entropy"
+)
+
+func TestDecayed(t *testing.T) {
+	now := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	commits := []commitinfo.CommitInfo{
+		{Author: "alice", Filename: "a.go", Timestamp: now.AddDate(0, 0, -60), Weight: 1},
+		{Author: "alice", Filename: "a.go", Timestamp: now.AddDate(0, 0, -60), Weight: 1},
+		{Author: "alice", Filename: "a.go", Timestamp: now.AddDate(0, 0, -60), Weight: 1},
+		{Author: "alice", Filename: "a.go", Timestamp: now.AddDate(0, 0, -60), Weight: 1},
+		{Author: "bob", Filename: "a.go", Timestamp: now, Weight: 1},
+	}
+
+	raw := make(fileAuthorCounts)
+	decayedCounts := make(fileAuthorCounts)
+	decay := entropy.Decay{HalfLife: 30 * 24 * time.Hour, Now: now}
+	for _, commit := range commits {
+		raw.Add(commit)
+		decayed{decay, []accumulator{decayedCounts}}.Add(commit)
+	}
+
+	assert.Equal(t, fileAuthorCounts{"a.go": {"alice": 4, "bob": 1}}, raw)
+	// alice's four commits two half-lives ago weigh as much as bob's one
+	assert.InDelta(t, 1.0, decayedCounts["a.go"]["alice"], 1e-9)
+	assert.InDelta(t, 1.0, entropy.EntropyByFile(decayedCounts)[0].Entropy, 1e-6)
+	assert.Less(t, entropy.EntropyByFile(raw)[0].Entropy, 1.0)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"techdebt/components/busfactor"
+	"techdebt/components/entropy"
+	"techdebt/components/git"
+)
+
+// fileDelta is how the ownership of one file changed on a branch.
+type fileDelta struct {
+	Filename string
+	// BaseFilename is the name the file had before the branch, if the
+	// branch renamed it.
+	BaseFilename    string
+	EntropyBefore   float64
+	EntropyAfter    float64
+	BusFactorBefore int
+	BusFactorAfter  int
+	// New is set for files without history before the branch.
+	New bool
+}
+
+// Change is the entropy difference, negative when knowledge of the file
+// became more concentrated.
+func (d fileDelta) Change() float64 {
+	return d.EntropyAfter - d.EntropyBefore
+}
+
+// deltaReport compares the history up to the merge base of a branch with
+// the history including the branch.
+type deltaReport struct {
+	Base, Head, MergeBase string
+	Before, After         repoReport
+	// Files lists the files whose entropy or bus factor changed, biggest
+	// entropy drop first.
+	Files []fileDelta
+}
+
+// exitRegression is the exit status of a -base run that found regressions,
+// so that they can be told apart from the status 1 of a failed run.
+const exitRegression = 2
+
+// regressions returns the files whose entropy dropped by more than
+// maxDrop bits or whose bus factor fell by more than maxBusFactorDrop, and
+// whether the repository score dropped by more than maxDrop.
+func (r deltaReport) regressions(maxDrop float64, maxBusFactorDrop int) ([]fileDelta, bool) {
+	var files []fileDelta
+	for _, file := range r.Files {
+		if file.New {
+			continue
+		}
+		if file.Change() < -maxDrop || file.BusFactorBefore-file.BusFactorAfter > maxBusFactorDrop {
+			files = append(files, file)
+		}
+	}
+	return files, r.After.Score-r.Before.Score < -maxDrop
+}
+
+// analyzeDelta runs the history analysis of opts once up to the merge base
+// of base and head and once up to head.
+func analyzeDelta(ctx context.Context, opts git.HistoryOptions, base, head string, byLines bool) (deltaReport, error) {
+	report := deltaReport{Base: base, Head: head}
+
+	mergeBase, err := git.MergeBase(ctx, opts, base, head)
+	if err != nil {
+		return report, err
+	}
+	report.MergeBase = mergeBase
+
+	opts.Ref = mergeBase
+	beforeNames := make(fileNames)
+	before, beforeFiles, err := analyzeRepo(ctx, base, opts, byLines, beforeNames)
+	if err != nil {
+		return report, fmt.Errorf("history up to %s: %w", mergeBase, err)
+	}
+	opts.Ref = head
+	afterNames := make(fileNames)
+	after, afterFiles, err := analyzeRepo(ctx, head, opts, byLines, afterNames)
+	if err != nil {
+		return report, fmt.Errorf("history up to %s: %w", head, err)
+	}
+	report.Before, report.After = before, after
+
+	report.Files = compareFiles(beforeFiles, afterFiles, beforeNames, afterNames)
+	return report, nil
+}
+
+// compareFiles compares the author counts of every file before and after a
+// branch, biggest entropy drop first. The names of both histories match a
+// file renamed on the branch to its name before it.
+func compareFiles(before, after fileAuthorCounts, beforeNames, afterNames fileNames) []fileDelta {
+	// the commits before the branch name a file renamed on it by its
+	// current name in one history and by its old one in the other
+	baseNames := make(map[string]string)
+	for path, name := range afterNames {
+		if baseName, ok := beforeNames[path]; ok {
+			baseNames[name] = baseName
+		}
+	}
+
+	beforeEntropy := make(map[string]float64)
+	for _, fe := range entropy.EntropyByFile(before) {
+		beforeEntropy[fe.Filename] = fe.Entropy
+	}
+
+	var files []fileDelta
+	for _, fe := range entropy.EntropyByFile(after) {
+		delta := fileDelta{
+			Filename:       fe.Filename,
+			EntropyAfter:   fe.Entropy,
+			BusFactorAfter: busfactor.File(after[fe.Filename]),
+		}
+		baseName, ok := baseNames[fe.Filename]
+		if !ok {
+			baseName = fe.Filename
+		}
+		if baseName != fe.Filename {
+			delta.BaseFilename = baseName
+		}
+		if authors, ok := before[baseName]; ok {
+			delta.EntropyBefore = beforeEntropy[baseName]
+			delta.BusFactorBefore = busfactor.File(authors)
+		} else {
+			delta.New = true
+		}
+
+		if delta.New || math.Abs(delta.Change()) > 1e-9 || delta.BusFactorBefore != delta.BusFactorAfter {
+			files = append(files, delta)
+		}
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].Change() < files[j].Change()
+	})
+	return files
+}
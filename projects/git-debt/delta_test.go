@@ -0,0 +1,93 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareFiles(t *testing.T) {
+	// alice and bob wrote a.go and alice c.go before the branch, which
+	// renamed a.go to b.go, changed it twice and added d.go
+	before := fileAuthorCounts{
+		"a.go": {"alice": 1, "bob": 1},
+		"c.go": {"alice": 1},
+	}
+	beforeNames := fileNames{
+		{Hash: "1", Path: "a.go"}: "a.go",
+		{Hash: "1", Path: "c.go"}: "c.go",
+		{Hash: "2", Path: "a.go"}: "a.go",
+	}
+	after := fileAuthorCounts{
+		"b.go": {"alice": 1, "bob": 1, "carol": 2},
+		"c.go": {"alice": 1},
+		"d.go": {"carol": 1},
+	}
+	afterNames := fileNames{
+		{Hash: "1", Path: "a.go"}: "b.go",
+		{Hash: "1", Path: "c.go"}: "c.go",
+		{Hash: "2", Path: "a.go"}: "b.go",
+		{Hash: "3", Path: "b.go"}: "b.go",
+		{Hash: "4", Path: "b.go"}: "b.go",
+		{Hash: "4", Path: "d.go"}: "d.go",
+	}
+
+	files := compareFiles(before, after, beforeNames, afterNames)
+
+	// c.go did not change, and the new file has no change, which sorts it
+	// before the renamed one
+	require.Len(t, files, 2)
+	assert.Equal(t, fileDelta{Filename: "d.go", BusFactorAfter: 1, New: true}, files[0])
+
+	renamed := files[1]
+	assert.Equal(t, "b.go", renamed.Filename)
+	assert.Equal(t, "a.go", renamed.BaseFilename)
+	assert.False(t, renamed.New)
+	assert.InDelta(t, 1, renamed.EntropyBefore, 1e-6)
+	assert.InDelta(t, 1.5, renamed.EntropyAfter, 1e-6)
+}
+
+func TestRegressions(t *testing.T) {
+	files := []fileDelta{
+		{Filename: "drop.go", EntropyBefore: 1, EntropyAfter: 0.5},
+		{Filename: "small.go", EntropyBefore: 1, EntropyAfter: 0.95},
+		{Filename: "rise.go", EntropyBefore: 0.5, EntropyAfter: 1},
+		{Filename: "owner.go", EntropyBefore: 1, EntropyAfter: 1, BusFactorBefore: 3, BusFactorAfter: 1},
+		{Filename: "new.go", EntropyAfter: 0, New: true},
+	}
+
+	for _, test := range []struct {
+		name          string
+		before        float64
+		after         float64
+		threshold     float64
+		busFactorDrop int
+		files         []string
+		score         bool
+	}{
+		{name: "default threshold", before: 1, after: 1, threshold: 0.1, files: []string{"drop.go", "owner.go"}},
+		{name: "tight threshold", before: 1, after: 0.95, threshold: 0.01, files: []string{"drop.go", "small.go", "owner.go"}, score: true},
+		{name: "score drop", before: 1, after: 0.8, threshold: 0.1, files: []string{"drop.go", "owner.go"}, score: true},
+		{name: "drop at the threshold", before: 1, after: 0.5, threshold: 0.5, busFactorDrop: 2},
+		{name: "score rise", before: 0.5, after: 1, threshold: 0.6, busFactorDrop: 2},
+		// owner.go lost two of its three authors without any entropy drop
+		{name: "bus factor drop", before: 1, after: 1, threshold: 0.6, busFactorDrop: 1, files: []string{"owner.go"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			report := deltaReport{
+				Before: repoReport{Score: test.before},
+				After:  repoReport{Score: test.after},
+				Files:  files,
+			}
+			regressed, score := report.regressions(test.threshold, test.busFactorDrop)
+
+			var names []string
+			for _, file := range regressed {
+				names = append(names, file.Filename)
+			}
+			assert.Equal(t, test.files, names)
+			assert.Equal(t, test.score, score)
+		})
+	}
+}
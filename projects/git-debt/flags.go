@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"techdebt/components/git"
+)
+
+// stringList is a flag that can be given several times.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// relativeDate matches a time ago such as "90d", "2w", "6mo" or "1y".
+var relativeDate = regexp.MustCompile(`^(\d+)(d|w|mo|y)$`)
+
+// parseDate parses a date flag: a day like 2024-01-31, an RFC 3339 time, or
+// a time relative to now like 6mo for six months ago.
+func parseDate(value string, now time.Time) (time.Time, error) {
+	if m := relativeDate.FindStringSubmatch(value); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date %q: %w", value, err)
+		}
+		switch m[2] {
+		case "d":
+			return now.AddDate(0, 0, -n), nil
+		case "w":
+			return now.AddDate(0, 0, -7*n), nil
+		case "mo":
+			return now.AddDate(0, -n, 0), nil
+		default:
+			return now.AddDate(-n, 0, 0), nil
+		}
+	}
+
+	for _, layout := range []string{time.DateOnly, time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q, want YYYY-MM-DD, an RFC 3339 time or an age like 90d, 2w, 6mo or 1y", value)
+}
+
+// parseHalfLife parses a duration flag: an age like 90d, 2w, 6mo or 1y, or
+// a Go duration like 720h.
+func parseHalfLife(value string) (time.Duration, error) {
+	return parseAge(value, "half-life")
+}
+
+// parseAge parses a duration flag like parseHalfLife, naming it what in
+// errors.
+func parseAge(value, what string) (time.Duration, error) {
+	if m := relativeDate.FindStringSubmatch(value); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s %q: %w", what, value, err)
+		}
+		day := 24 * time.Hour
+		unit := map[string]time.Duration{
+			"d":  day,
+			"w":  7 * day,
+			"mo": day * 365 / 12,
+			"y":  day * 365,
+		}[m[2]]
+		return time.Duration(n) * unit, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q, want an age like 90d, 2w, 6mo or 1y", what, value)
+	}
+	return d, nil
+}
+
+// asOf is an -as-of flag. Whether it names a ref or a date depends on the
+// repository it is applied to.
+type asOf struct {
+	value string
+	now   time.Time
+	// commitTime looks a ref up in a repository, git.CommitTime outside of
+	// tests.
+	commitTime func(ctx context.Context, opts git.HistoryOptions, ref string) (time.Time, error)
+}
+
+// apply makes opts start at the commit the flag names and returns the time
+// the analysis is as of: the committer time of a ref, the date itself, or
+// now without the flag. A ref of the repository wins, so that a tag like
+// 2024-01-01 is not taken for a date.
+func (a asOf) apply(ctx context.Context, opts git.HistoryOptions) (git.HistoryOptions, time.Time, error) {
+	if a.value == "" {
+		return opts, a.now, nil
+	}
+
+	when, err := a.commitTime(ctx, opts, a.value)
+	if err == nil {
+		opts.Ref = a.value
+		return opts, when, nil
+	}
+	if !errors.Is(err, git.ErrUnknownRef) {
+		return opts, time.Time{}, err
+	}
+
+	date, err := parseDate(a.value, a.now)
+	if err != nil {
+		return opts, time.Time{}, fmt.Errorf("-as-of %q is neither a ref nor a date: %w", a.value, err)
+	}
+	opts.AsOf = date
+	return opts, date, nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"techdebt/components/git"
+)
+
+func TestParseDate(t *testing.T) {
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+	for _, test := range []struct {
+		value    string
+		expected time.Time
+	}{
+		{"2024-01-31", time.Date(2024, 1, 31, 0, 0, 0, 0, time.Local)},
+		{"2024-01-31T10:00:00Z", time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)},
+		{"2024-01-31T10:00:00+02:00", time.Date(2024, 1, 31, 8, 0, 0, 0, time.UTC)},
+		{"90d", now.AddDate(0, 0, -90)},
+		{"2w", now.AddDate(0, 0, -14)},
+		// AddDate normalizes February 31 to March 2
+		{"1mo", time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC)},
+		{"1y", now.AddDate(-1, 0, 0)},
+		{"0d", now},
+	} {
+		date, err := parseDate(test.value, now)
+		require.NoError(t, err, test.value)
+		assert.True(t, test.expected.Equal(date), "%s: %s", test.value, date)
+	}
+
+	for _, value := range []string{"", "yesterday", "2024-13-01", "90", "-2w", "6m", "2024/01/31"} {
+		_, err := parseDate(value, now)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	day := 24 * time.Hour
+	for _, test := range []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"90d", 90 * day},
+		{"2w", 14 * day},
+		{"6mo", 6 * day * 365 / 12},
+		{"1y", 365 * day},
+		{"0d", 0},
+		{"720h", 720 * time.Hour},
+		{"90m", 90 * time.Minute},
+	} {
+		age, err := parseAge(test.value, "period")
+		require.NoError(t, err, test.value)
+		assert.Equal(t, test.expected, age, test.value)
+
+		halfLife, err := parseHalfLife(test.value)
+		require.NoError(t, err, test.value)
+		assert.Equal(t, test.expected, halfLife, test.value)
+	}
+
+	for _, value := range []string{"", "soon", "-2w", "-1h", "6x", "1.5y", "2024-01-01"} {
+		_, err := parseAge(value, "period")
+		assert.ErrorContains(t, err, "invalid period", value)
+
+		_, err = parseHalfLife(value)
+		assert.ErrorContains(t, err, "invalid half-life", value)
+	}
+}
+
+func TestAsOfApply(t *testing.T) {
+	ctx := context.Background()
+	first := time.Date(2024, 1, 1, 13, 0, 0, 0, time.UTC)
+	head := first.Add(time.Hour)
+	refs := map[string]time.Time{
+		"2024-01-01": first,
+		"90d":        first,
+		"HEAD~1":     first,
+		"HEAD":       head,
+	}
+	commitTime := func(ctx context.Context, opts git.HistoryOptions, ref string) (time.Time, error) {
+		if when, ok := refs[ref]; ok {
+			return when, nil
+		}
+		return time.Time{}, fmt.Errorf("%w: %s", git.ErrUnknownRef, ref)
+	}
+
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	opts := git.HistoryOptions{RepoPath: "repo"}
+	for _, test := range []struct {
+		value string
+		ref   string
+		date  time.Time
+		when  time.Time
+	}{
+		// tags that look like dates are refs first, as of their commit
+		{value: "2024-01-01", ref: "2024-01-01", when: first},
+		{value: "90d", ref: "90d", when: first},
+		{value: "HEAD~1", ref: "HEAD~1", when: first},
+		{value: "HEAD", ref: "HEAD", when: head},
+		{value: "2w", date: now.AddDate(0, 0, -14), when: now.AddDate(0, 0, -14)},
+		{value: "2024-02-01", date: time.Date(2024, 2, 1, 0, 0, 0, 0, time.Local), when: time.Date(2024, 2, 1, 0, 0, 0, 0, time.Local)},
+		{value: "", when: now},
+	} {
+		applied, when, err := asOf{value: test.value, now: now, commitTime: commitTime}.apply(ctx, opts)
+		require.NoError(t, err, test.value)
+		assert.Equal(t, test.ref, applied.Ref, test.value)
+		assert.True(t, test.date.Equal(applied.AsOf), "%s: %s", test.value, applied.AsOf)
+		assert.True(t, test.when.Equal(when), "%s: %s", test.value, when)
+	}
+
+	_, _, err := asOf{value: "nope", now: now, commitTime: commitTime}.apply(ctx, opts)
+	assert.ErrorContains(t, err, "neither a ref nor a date")
+
+	// a repository that cannot be read is not a date
+	broken := errors.New("broken repository")
+	_, _, err = asOf{value: "2w", now: now, commitTime: func(context.Context, git.HistoryOptions, string) (time.Time, error) {
+		return time.Time{}, broken
+	}}.apply(ctx, opts)
+	assert.ErrorIs(t, err, broken)
+}
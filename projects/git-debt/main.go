@@ -1,10 +1,16 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"log"
 	"os"
+	"time"
 
+	"techdebt/components/busfactor"
+	"techdebt/components/changeentropy"
 	"techdebt/components/commitinfo"
 	"techdebt/components/entropy"
 	"techdebt/components/git"
@@ -27,6 +33,23 @@ func writeFileEntropies(filename string, entropies []entropy.FileEntropy) error
 	return nil
 }
 
+// writeTree writes a directory tree to a file in JSON format.
+func writeTree(filename string, tree *entropy.DirectoryNode) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("could not create file: %w", err)
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(tree); err != nil {
+		return fmt.Errorf("could not write JSON to file: %w", err)
+	}
+
+	return nil
+}
+
 // aggregateByFile aggregates CommitInfo data into a map where the key is the filename
 // and the value is a list of authors who committed to that file.
 func aggregateByFile(commits []commitinfo.CommitInfo) map[string][]string {
@@ -67,151 +90,350 @@ func aggregateByFileWithRepeats(commits []commitinfo.CommitInfo) map[string][]st
 	return aggregated
 }
 
-// aggregateByFile aggregates CommitInfo data into a map where the key is the filename
-// and the value is a list of authors who committed to that file.
-func aggregateCountsByFile(commits []commitinfo.CommitInfo) map[string]map[string]int {
-	// Initialize the map
-	// {
-	//  filename1: {author1: 1, author2: 6},
-	//  filename2: {author0: 5, author1: 3}
-	// }
-	aggregated := make(map[string]map[string]int)
+// calcRepoEntropy prints the decomposition of the author×file entropy and
+// returns the repository score: H(author|file), how uncertain the author
+// of a change stays once its file is known.
+func calcRepoEntropy(fileAuthors fileAuthorCounts) float64 {
+	d := entropy.Decompose(fileAuthors)
+	printDecomposition(d)
 
-	for _, commit := range commits {
-		// Check if the filename already exists in the map
-		if _, exists := aggregated[commit.Filename]; !exists {
-			aggregated[commit.Filename] = make(map[string]int)
-		}
+	return d.AuthorGivenFile
+}
 
-		// inc the author count
-		if _, exists := aggregated[commit.Filename][commit.Author]; !exists {
-			aggregated[commit.Filename][commit.Author] = 0
-		}
+func calcEntroyDemo() {
+	arr := []int{1, 1, 2, 2, 3, 3, 3}
+	e := entropy.CalculateEntropyOfOccurances(arr)
+	fmt.Printf("Entropy: %.4f\n", e)
+}
+
+func calcEntroyByFile(aggregatedCounts fileAuthorCounts) {
 
-		aggregated[commit.Filename][commit.Author]++
+	// fmt.Printf("aggregatedCounts %v\n", aggregatedCounts)
+	fileEntropies := entropy.EntropyByFile(aggregatedCounts)
 
+	totalEntropy := 0.0
+	for _, fe := range fileEntropies {
+		totalEntropy = totalEntropy + fe.Entropy
 	}
+	avgEntropy := totalEntropy / float64(len(fileEntropies))
+
+	fmt.Printf("Repo Entropy (average of all files):%f\n", avgEntropy)
+
+	fmt.Println("file entropies:")
+	entropy.PrintFileEntropySlice(fileEntropies)
 
-	return aggregated
 }
 
-func transformMapCountsToArray(countmap map[string]int) []int {
-	var res []int
-	var i int
+func main() {
+	renames := flag.Bool("renames", true, "attribute the history of renamed files to their current path")
+	renameScore := flag.Uint("rename-score", git.DefaultRenameScore, "similarity percentage needed to detect a rename")
+	lineage := flag.Bool("lineage", false, "print the earlier paths of renamed files")
+	mailmap := flag.Bool("mailmap", true, "resolve authors through the repository's .mailmap")
+	aliases := flag.String("aliases", "", "extra author alias file in .mailmap format")
+	coauthors := flag.String("coauthors", "off", "credit Co-authored-by trailers: off, full or split")
+	mergeEmails := flag.Bool("merge-emails", true, "count authors sharing an email as one person")
+	merges := flag.String("merges", "resolution", "merge commits: resolution (credit the merger for conflict fixes), skip, or first-parent (mainline only)")
+	bots := flag.Bool("bots", false, "include commits by Dependabot, Renovate and other automation accounts")
+	var excludeAuthors, excludeMessages stringList
+	flag.Var(&excludeAuthors, "exclude-author", "exclude commits whose author name or email matches this regexp (repeatable)")
+	flag.Var(&excludeMessages, "exclude-message", "exclude commits whose message matches this regexp (repeatable)")
+	var includePaths, excludePaths stringList
+	flag.Var(&includePaths, "include", "only analyze files matching this .gitignore-style pattern (repeatable)")
+	flag.Var(&excludePaths, "exclude", "skip files matching this .gitignore-style pattern (repeatable)")
+	ignoreFile := flag.Bool("ignore-file", true, "skip the files listed in the repository's "+git.IgnoreFile)
+	generated := flag.Bool("generated", false, "include generated and vendored files, detected from .gitattributes and \"Code generated\" headers")
+	weight := flag.String("weight", "commits", "weight each author's share by commits or by lines changed")
+	blame := flag.Bool("blame", false, "also report current-ownership entropy from blaming the files at HEAD")
+	workspaceManifest := flag.String("workspace", "", "analyze every repository listed in this JSON manifest instead of a single repository")
+	cache := flag.Bool("cache", true, "reuse per-commit changes cached in the repository's .git/techdebt directory")
+	since := flag.String("since", "", "only count commits made on or after this date: YYYY-MM-DD, RFC 3339, or an age like 90d, 2w, 6mo or 1y")
+	until := flag.String("until", "", "only count commits made on or before this date, in the same formats as -since")
+	last := flag.Int("last", 0, "only count the last N commits that changed analyzed files")
+	asOfFlag := flag.String("as-of", "", "analyze the repository as it was at this commit, tag or date, a ref winning over a date of the same name")
+	fileTable := flag.Bool("files", false, "print the entropy, evenness and effective number of authors of every file")
+	sortFiles := flag.String("sort", "normalized", "sort the -files table by filename, entropy, normalized or effective, ascending")
+	tree := flag.Bool("tree", false, "print the entropy of every directory, pooled over the files below it")
+	treeJSON := flag.String("tree-json", "", "write the directory tree as JSON to this file")
+	treeDepth := flag.Int("tree-depth", 3, "directory levels below the root shown by -tree and -tree-json, or -1 for all")
+	treeLowest := flag.Int("tree-lowest", 3, "number of lowest-entropy files and subdirectories listed per directory")
+	truckFactor := flag.Bool("busfactor", false, "print the truck factor of the repository and every directory, and the bus factor of every file")
+	ownerThreshold := flag.Float64("owner-threshold", busfactor.DefaultOwnershipThreshold, "share of a file's top author's weight an author needs to count as an owner for -busfactor")
+	measureNames := flag.String("measures", "", "also compute these concentration measures per file and repo, comma-separated: shannon, gini, hhi, top-share, renyi-<order>")
+	hcmPeriod := flag.String("hcm-period", "", "rank files by Hassan's History Complexity Metric over periods of this length, e.g. 2w or 1mo")
+	hcmBurst := flag.String("hcm-burst", "", "rank files by the History Complexity Metric over bursts of changes separated by more than this quiet time, e.g. 12h or 2d")
+	hcmContribution := flag.String("hcm-contribution", "full", "charge the files changed in a period with its full entropy, their share of its changes, or an even split")
+	hcmDecay := flag.Float64("hcm-decay", 1, "decay factor of the decayed History Complexity Metrics")
+	halfLife := flag.String("half-life", "", "also report entropy with commits losing half their weight per this age, e.g. 180d or 1y")
+	base := flag.String("base", "", "compare the history including -head against the history up to its merge base with this ref")
+	head := flag.String("head", "HEAD", "branch compared against -base")
+	maxRegression := flag.Float64("max-regression", 0.1, "with -base, exit with status 2 when the score or a file's entropy drops by more than this many bits")
+	maxBusFactorDrop := flag.Int("max-busfactor-drop", 0, "with -base, exit with status 2 when a file's bus factor falls by more than this")
+	workers := flag.Int("workers", 0, "number of commits to diff in parallel (0 uses every CPU)")
+	backend := flag.String("backend", "go-git", "read history with go-git, or git to run the git binary (faster on large repositories)")
+	flag.Parse()
+
+	coAuthorCredit, err := git.ParseCoAuthorCredit(*coauthors)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	res = make([]int, len(countmap))
-	i = 0
-	for _, count := range countmap {
-		res[i] = count
-		i++
+	mergeMode, err := git.ParseMergeMode(*merges)
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	return res
-}
+	historyBackend, err := git.ParseBackend(*backend)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-// aggregateByFile aggregates CommitInfo data into a map where the key is the filename
-// and the value is a list of authors who committed to that file.
-func aggregateAuthorCounts(commits []commitinfo.CommitInfo) map[string]int {
-	// Initialize the map
-	// {
-	//  author0: 5,
-	//  author1: 1, ...
-	// }
-	aggregated := make(map[string]int)
+	if *weight != "commits" && *weight != "lines" {
+		log.Fatalf("unknown weight %q, want commits or lines", *weight)
+	}
 
-	for _, commit := range commits {
-		if _, exists := aggregated[commit.Author]; !exists {
-			aggregated[commit.Author] = 0
+	filter := git.NewCommitFilter()
+	if !*bots {
+		filter.ExcludeBots()
+	}
+	for _, pattern := range excludeAuthors {
+		if err := filter.ExcludeAuthor(pattern); err != nil {
+			log.Fatal(err)
+		}
+	}
+	for _, pattern := range excludeMessages {
+		if err := filter.ExcludeMessage(pattern); err != nil {
+			log.Fatal(err)
 		}
-		aggregated[commit.Author]++
 	}
 
-	return aggregated
-}
+	now := time.Now()
+	var sinceTime, untilTime time.Time
+	if *since != "" {
+		if sinceTime, err = parseDate(*since, now); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *until != "" {
+		if untilTime, err = parseDate(*until, now); err != nil {
+			log.Fatal(err)
+		}
+	}
 
-func aggregateFileCounts(commits []commitinfo.CommitInfo) map[string]int {
-	// Initialize the map
-	// {
-	//  author0: 5,
-	//  author1: 1, ...
-	// }
-	aggregated := make(map[string]int)
+	sortColumn, err := entropy.ParseColumn(*sortFiles)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	for _, commit := range commits {
-		if _, exists := aggregated[commit.Filename]; !exists {
-			aggregated[commit.Filename] = 0
+	var measures []entropy.Measure
+	if *measureNames != "" {
+		if measures, err = entropy.ParseMeasures(*measureNames); err != nil {
+			log.Fatal(err)
 		}
-		aggregated[commit.Filename]++
 	}
 
-	return aggregated
-}
+	var periodLength, burstGap time.Duration
+	if *hcmPeriod != "" && *hcmBurst != "" {
+		log.Fatal("-hcm-period and -hcm-burst are mutually exclusive")
+	}
+	if *hcmPeriod != "" {
+		if periodLength, err = parseAge(*hcmPeriod, "period"); err != nil {
+			log.Fatal(err)
+		}
+		if periodLength == 0 {
+			log.Fatal("-hcm-period must be positive")
+		}
+	}
+	if *hcmBurst != "" {
+		if burstGap, err = parseAge(*hcmBurst, "burst gap"); err != nil {
+			log.Fatal(err)
+		}
+	}
+	contribution, err := changeentropy.ParseContribution(*hcmContribution)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *hcmDecay <= 0 {
+		log.Fatal("-hcm-decay must be positive")
+	}
+	if *treeLowest < 0 {
+		log.Fatal("-tree-lowest must not be negative")
+	}
+	if *maxBusFactorDrop < 0 {
+		log.Fatal("-max-busfactor-drop must not be negative")
+	}
 
-func calcRepoEntropy(commits []commitinfo.CommitInfo) float64 {
-	// entropy by author
-	agg_counts_map := aggregateAuthorCounts(commits)
-	agg_counts_arr := transformMapCountsToArray(agg_counts_map)
-	entropy_author := float64(entropy.CalculateEntropyOfCounts(agg_counts_arr))
-	fmt.Printf("author entropy: %.3f\n", entropy_author)
+	var decay entropy.Decay
+	if *halfLife != "" {
+		if decay.HalfLife, err = parseHalfLife(*halfLife); err != nil {
+			log.Fatal(err)
+		}
+	}
 
-	// entropy by file
-	agg_counts_map = aggregateFileCounts(commits)
-	agg_counts_arr = transformMapCountsToArray(agg_counts_map)
-	entropy_file := float64(entropy.CalculateEntropyOfCounts(agg_counts_arr))
-	fmt.Printf("file entropy: %.3f\n", entropy_file)
+	if *asOfFlag != "" && *base != "" {
+		log.Fatal("-as-of cannot be combined with -base")
+	}
 
-	return (entropy_file + entropy_author) / 2.0
-}
+	repoPath := "." // Define local repo directory
+	if flag.NArg() > 0 {
+		repoPath = flag.Arg(0)
+	}
 
-func calcEntroyDemo() {
-	arr := []int{1, 1, 2, 2, 3, 3, 3}
-	e := entropy.CalculateEntropyOfOccurances(arr)
-	fmt.Printf("Entropy: %.4f\n", e)
-}
+	ctx := context.Background()
+	opts := git.HistoryOptions{
+		RepoPath:         repoPath,
+		Since:            sinceTime,
+		Until:            untilTime,
+		MaxCount:         *last,
+		DetectRenames:    *renames,
+		RenameScore:      *renameScore,
+		Cache:            *cache,
+		UseMailmap:       *mailmap,
+		AliasFile:        *aliases,
+		MergeByEmail:     *mergeEmails,
+		CoAuthors:        coAuthorCredit,
+		Filter:           filter,
+		Merges:           mergeMode,
+		LineStats:        *weight == "lines",
+		Backend:          historyBackend,
+		Workers:          *workers,
+		Include:          includePaths,
+		Exclude:          excludePaths,
+		UseIgnoreFile:    *ignoreFile,
+		ExcludeGenerated: !*generated,
+	}
 
-func calcEntroyByFile(commits []commitinfo.CommitInfo) {
+	analyzedAsOf := asOf{value: *asOfFlag, now: now, commitTime: git.CommitTime}
+	if *workspaceManifest != "" {
+		if err := runWorkspace(ctx, *workspaceManifest, opts, analyzedAsOf, *weight == "lines"); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println()
+		printFilterReport(filter)
+		return
+	}
 
-	aggregatedCounts := aggregateCountsByFile(commits)
+	if *base != "" {
+		report, err := analyzeDelta(ctx, opts, *base, *head, *weight == "lines")
+		if err != nil {
+			log.Fatal(err)
+		}
+		printDeltaReport(report)
 
-	// fmt.Printf("aggregatedCounts %v\n", aggregatedCounts)
-	fileEntropies := make([]entropy.FileEntropy, 0)
-	for filename, authorCountMap := range aggregatedCounts {
-		arr := []int{}
-		for _, count := range authorCountMap {
-			arr = append(arr, count)
+		files, score := report.regressions(*maxRegression, *maxBusFactorDrop)
+		if score || len(files) > 0 {
+			fmt.Printf("\nregression over %.2f bits or %d bus factor: %d files", *maxRegression, *maxBusFactorDrop, len(files))
+			if score {
+				fmt.Print(" and the repository score")
+			}
+			fmt.Println()
+			os.Exit(exitRegression)
 		}
-		// fmt.Printf("%s arr: %v\n", filename, arr)
-		e := entropy.CalculateEntropyOfOccurances(arr)
-		// fmt.Printf("entropy = %f\n", entropy)
-		fileEntropies = append(fileEntropies, entropy.FileEntropy{Filename: filename, Entropy: float64(e)})
+		return
 	}
 
-	totalEntropy := 0.0
-	for _, fe := range fileEntropies {
-		totalEntropy = totalEntropy + fe.Entropy
+	// decay relative to the analyzed point in time
+	opts, decay.Now, err = analyzedAsOf.apply(ctx, opts)
+	if err != nil {
+		log.Fatal(err)
 	}
-	avgEntropy := totalEntropy / float64(len(fileEntropies))
 
-	fmt.Printf("Repo Entropy (average of all files):%f\n", avgEntropy)
+	commits := history(ctx, opts, *weight == "lines")
 
-	fmt.Println("file entropies:")
-	entropy.PrintFileEntropySlice(fileEntropies)
+	authors := make(authorCounts)
+	files := make(fileCounts)
+	fileAuthors := make(fileAuthorCounts)
+	paths := make(git.PathLineage)
+	decayedFileAuthors := make(fileAuthorCounts)
+	accumulators := []accumulator{authors, files, fileAuthors, paths}
+	if decay.HalfLife > 0 {
+		accumulators = append(accumulators, decayed{decay, []accumulator{decayedFileAuthors}})
+	}
 
-}
+	directories := entropy.NewTreeBuilder()
+	if *tree || *treeJSON != "" {
+		accumulators = append(accumulators, directories)
+	}
 
-func main() {
-	var repoPath string
-	if len(os.Args) == 2 {
-		repoPath = os.Args[1]
-	} else {
-		repoPath = "." //"/Users/davidwright/Documents/javascript-dev/trebsirk.github.io"
-		// Define local repo directory
+	var changeHistory records
+	if *hcmPeriod != "" || *hcmBurst != "" {
+		accumulators = append(accumulators, &changeHistory)
+	}
+
+	if err := consume(commits, accumulators...); err != nil {
+		log.Fatalf("Failed to read commit history: %v", err)
 	}
 
-	var commits []commitinfo.CommitInfo = git.GetCommits(repoPath)
-	var overallEntropy float64 = calcRepoEntropy(commits)
+	printFilterReport(filter)
+	fmt.Println()
+
+	if *lineage {
+		printLineage(paths)
+		fmt.Println()
+	}
+
+	var overallEntropy float64 = calcRepoEntropy(fileAuthors)
 	fmt.Printf("overallEntropy = %f\n", overallEntropy)
 
+	if *fileTable {
+		fmt.Println()
+		printFileTable(fileAuthors, sortColumn)
+	}
+
+	if *tree || *treeJSON != "" {
+		root := directories.Build(*treeLowest).Truncate(*treeDepth)
+		if *tree {
+			fmt.Println()
+			printTree(root)
+		}
+		if *treeJSON != "" {
+			if err := writeTree(*treeJSON, root); err != nil {
+				log.Fatalf("Failed to write directory tree: %v", err)
+			}
+		}
+	}
+
+	if len(measures) > 0 {
+		fmt.Println()
+		printMeasures(measures,
+			entropy.MeasureAll(measures, transformMapCountsToArray(authors)),
+			entropy.MeasureAll(measures, transformMapCountsToArray(files)),
+			entropy.MeasuresByFile(fileAuthors, measures))
+	}
+
+	if *truckFactor {
+		fmt.Println()
+		matrix := busfactor.Matrix(fileAuthors)
+		printBusFactorReport(busfactor.ByDirectory(matrix, *ownerThreshold), busfactor.Files(matrix))
+	}
+
+	if *hcmPeriod != "" || *hcmBurst != "" {
+		var periods []changeentropy.Period
+		if *hcmPeriod != "" {
+			periods = changeentropy.ByInterval(changeHistory, periodLength)
+		} else {
+			periods = changeentropy.ByBurst(changeHistory, burstGap)
+		}
+		fmt.Println()
+		printHCMReport(periods, changeentropy.Scores(periods, contribution, *hcmDecay))
+	}
+
+	if decay.HalfLife > 0 {
+		fmt.Println()
+		fmt.Println("decayed:")
+		fmt.Printf("decayed overallEntropy = %f\n", calcRepoEntropy(decayedFileAuthors))
+		fmt.Println()
+		printDecayComparison(entropy.EntropyByFile(fileAuthors), entropy.EntropyByFile(decayedFileAuthors))
+	}
+
+	if *blame {
+		ownership, err := git.Ownership(ctx, opts)
+		if err != nil {
+			log.Fatalf("Failed to blame files: %v", err)
+		}
+		fmt.Println()
+		printOwnershipComparison(entropy.EntropyByFile(fileAuthors), entropy.OwnershipEntropy(ownership))
+	}
+
 	fmt.Println("commits")
 	// Output the commit information
 	// for _, commit := range commits {
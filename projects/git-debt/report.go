@@ -0,0 +1,352 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"techdebt/components/busfactor"
+	"techdebt/components/changeentropy"
+	"techdebt/components/entropy"
+	"techdebt/components/git"
+)
+
+// printLineage prints the earlier paths of every renamed file.
+func printLineage(lineage git.PathLineage) {
+	filenames := make([]string, 0, len(lineage))
+	for filename, paths := range lineage {
+		if len(paths) > 1 {
+			filenames = append(filenames, filename)
+		}
+	}
+	sort.Strings(filenames)
+
+	fmt.Println("file lineage:")
+	for _, filename := range filenames {
+		fmt.Println(strings.Join(lineage[filename], " <- "))
+	}
+}
+
+// printFilterReport prints how many commits each filter rule removed.
+func printFilterReport(filter *git.CommitFilter) {
+	removed := filter.Removed()
+
+	rules := make([]string, 0, len(removed))
+	total := 0
+	for rule, count := range removed {
+		rules = append(rules, rule)
+		total += count
+	}
+	sort.Strings(rules)
+
+	fmt.Printf("filtered commits: %d\n", total)
+	for _, rule := range rules {
+		fmt.Printf("  %s: %d\n", rule, removed[rule])
+	}
+}
+
+// printFileTable prints the entropy columns of every file, sorted by
+// column.
+func printFileTable(fileAuthors fileAuthorCounts, sortBy entropy.Column) {
+	files := entropy.EntropyByFile(fileAuthors)
+	entropy.SortBy(files, sortBy)
+	entropy.PrintFileEntropyTable(files)
+}
+
+// printOwnershipComparison prints historical-commit entropy and
+// current-ownership entropy side by side. Files that no longer exist have
+// no ownership; files without any counted commits have no history.
+func printOwnershipComparison(historical, ownership []entropy.FileEntropy) {
+	printEntropyColumns([]string{"Commits", "Ownership"}, historical, ownership)
+}
+
+// printDecayComparison prints raw and time-decayed commit entropy side by
+// side.
+func printDecayComparison(raw, decayed []entropy.FileEntropy) {
+	printEntropyColumns([]string{"Raw", "Decayed"}, raw, decayed)
+}
+
+// printEntropyColumns prints one row per file and one column of entropies
+// per header. A file missing from a column is shown as "-".
+func printEntropyColumns(headers []string, columns ...[]entropy.FileEntropy) {
+	type cell struct {
+		value float64
+		ok    bool
+	}
+	rows := make(map[string][]cell)
+	for i, column := range columns {
+		for _, fe := range column {
+			if rows[fe.Filename] == nil {
+				rows[fe.Filename] = make([]cell, len(columns))
+			}
+			rows[fe.Filename][i] = cell{fe.Entropy, true}
+		}
+	}
+
+	filenames := make([]string, 0, len(rows))
+	maxNameWidth := len("Filename")
+	for filename := range rows {
+		filenames = append(filenames, filename)
+		maxNameWidth = max(maxNameWidth, len(filename))
+	}
+	sort.Strings(filenames)
+
+	line := fmt.Sprintf("%-*s", maxNameWidth, "Filename")
+	for _, header := range headers {
+		line += fmt.Sprintf(" | %-9s", header)
+	}
+	fmt.Println(strings.TrimRight(line, " "))
+	fmt.Println("---------------------------")
+	for _, filename := range filenames {
+		line = fmt.Sprintf("%-*s", maxNameWidth, filename)
+		for _, c := range rows[filename] {
+			value := "-"
+			if c.ok {
+				value = fmt.Sprintf("%.4f", c.value)
+			}
+			line += fmt.Sprintf(" | %-9s", value)
+		}
+		fmt.Println(strings.TrimRight(line, " "))
+	}
+}
+
+// printWorkspaceReport prints the per-repository scores and the authors the
+// workspace depends on most.
+func printWorkspaceReport(report workspaceReport) {
+	maxNameWidth := len("Repo")
+	for _, repo := range report.Repos {
+		maxNameWidth = max(maxNameWidth, len(repo.Name))
+	}
+
+	fmt.Printf("%-*s | %7s | %5s | %6s | %6s | %6s | %s\n", maxNameWidth,
+		"Repo", "Authors", "Files", "Author", "File", "MI", "Score")
+	fmt.Println("---------------------------")
+	for _, repo := range report.Repos {
+		fmt.Printf("%-*s | %7d | %5d | %.4f | %.4f | %.4f | %.4f\n", maxNameWidth,
+			repo.Name, repo.Authors, repo.Files,
+			repo.Entropy.Author, repo.Entropy.File, repo.Entropy.MutualInformation, repo.Score)
+	}
+
+	fmt.Println()
+	fmt.Printf("workspace author entropy: %.3f\n", report.AuthorEntropy)
+
+	maxNameWidth = len("Author")
+	for _, author := range report.Authors {
+		maxNameWidth = max(maxNameWidth, len(author.Author))
+	}
+
+	fmt.Printf("%-*s | %6s | %5s | %10s | %s\n", maxNameWidth,
+		"Author", "Share", "Repos", "Sole files", "Dominant in")
+	fmt.Println("---------------------------")
+	for _, author := range report.Authors {
+		fmt.Printf("%-*s | %5.1f%% | %5d | %10d | %s\n", maxNameWidth,
+			author.Author, 100*author.Weight, author.Repos, author.SoleFiles,
+			strings.Join(author.DominantRepos, ", "))
+	}
+}
+
+// printDeltaReport prints how a branch changed the repository scores and
+// the files whose ownership changed.
+func printDeltaReport(report deltaReport) {
+	fmt.Printf("delta of %s against %s (merge base %.12s)\n", report.Head, report.Base, report.MergeBase)
+	before, after := report.Before.Entropy, report.After.Entropy
+	fmt.Printf("%-24s | %8s | %8s | %s\n", "", "Before", "After", "Change")
+	fmt.Println("---------------------------")
+	for _, row := range []struct {
+		name          string
+		before, after float64
+	}{
+		{"H(author)", before.Author, after.Author},
+		{"H(file)", before.File, after.File},
+		{"H(author, file)", before.Joint, after.Joint},
+		{"H(file | author)", before.FileGivenAuthor, after.FileGivenAuthor},
+		{"I(author; file)", before.MutualInformation, after.MutualInformation},
+		{"score H(author | file)", report.Before.Score, report.After.Score},
+	} {
+		fmt.Printf("%-24s | %8.4f | %8.4f | %+.4f\n", row.name, row.before, row.after, row.after-row.before)
+	}
+
+	fmt.Println()
+	names := make([]string, len(report.Files))
+	maxNameWidth := len("Filename")
+	for i, file := range report.Files {
+		names[i] = file.Filename
+		if file.BaseFilename != "" {
+			names[i] = file.BaseFilename + " -> " + file.Filename
+		}
+		maxNameWidth = max(maxNameWidth, len(names[i]))
+	}
+
+	fmt.Printf("%-*s | %8s | %8s | %7s | %s\n", maxNameWidth,
+		"Filename", "Before", "After", "Change", "Bus factor")
+	fmt.Println("---------------------------")
+	for i, file := range report.Files {
+		if file.New {
+			fmt.Printf("%-*s | %8s | %8.4f | %7s | %d (new)\n", maxNameWidth,
+				names[i], "-", file.EntropyAfter, "-", file.BusFactorAfter)
+			continue
+		}
+		fmt.Printf("%-*s | %8.4f | %8.4f | %+7.4f | %d -> %d\n", maxNameWidth,
+			names[i], file.EntropyBefore, file.EntropyAfter, file.Change(),
+			file.BusFactorBefore, file.BusFactorAfter)
+	}
+}
+
+// printBusFactorReport prints the truck factor of the repository and of
+// every directory, and the bus factor of every file.
+func printBusFactorReport(dirs []busfactor.DirectoryResult, files []busfactor.FileResult) {
+	for _, dir := range dirs {
+		if dir.Dir == "." {
+			fmt.Printf("truck factor: %d (%s)\n", dir.TruckFactor, strings.Join(dir.Authors, ", "))
+		}
+	}
+
+	fmt.Println()
+	maxNameWidth := len("Directory")
+	for _, dir := range dirs {
+		maxNameWidth = max(maxNameWidth, len(dir.Dir))
+	}
+	fmt.Printf("%-*s | %5s | %12s | %s\n", maxNameWidth, "Directory", "Files", "Truck factor", "Authors")
+	fmt.Println("---------------------------")
+	for _, dir := range dirs {
+		fmt.Printf("%-*s | %5d | %12d | %s\n", maxNameWidth,
+			dir.Dir, dir.Files, dir.TruckFactor, strings.Join(dir.Authors, ", "))
+	}
+
+	fmt.Println()
+	maxNameWidth = len("Filename")
+	for _, file := range files {
+		maxNameWidth = max(maxNameWidth, len(file.Filename))
+	}
+	fmt.Printf("%-*s | %s\n", maxNameWidth, "Filename", "Bus factor")
+	fmt.Println("---------------------------")
+	for _, file := range files {
+		fmt.Printf("%-*s | %d\n", maxNameWidth, file.Filename, file.BusFactor)
+	}
+}
+
+// printMeasures prints the selected measures of the repository's author
+// and file distributions, then of every file's authors.
+func printMeasures(measures []entropy.Measure, authors, files []float64, perFile []entropy.FileMeasures) {
+	maxNameWidth := len("Filename")
+	for _, file := range perFile {
+		maxNameWidth = max(maxNameWidth, len(file.Filename))
+	}
+	printRow := func(name string, values []string) {
+		line := fmt.Sprintf("%-*s", maxNameWidth, name)
+		for i, value := range values {
+			line += fmt.Sprintf(" | %*s", max(9, len(measures[i].Name())), value)
+		}
+		fmt.Println(line)
+	}
+	format := func(values []float64) []string {
+		formatted := make([]string, len(values))
+		for i, value := range values {
+			formatted[i] = fmt.Sprintf("%.4f", value)
+		}
+		return formatted
+	}
+
+	names := make([]string, len(measures))
+	for i, m := range measures {
+		names[i] = m.Name()
+	}
+
+	printRow("Repo", names)
+	fmt.Println("---------------------------")
+	printRow("by author", format(authors))
+	printRow("by file", format(files))
+
+	fmt.Println()
+	printRow("Filename", names)
+	fmt.Println("---------------------------")
+	for _, file := range perFile {
+		printRow(file.Filename, format(file.Values))
+	}
+}
+
+// printHCMReport prints the entropy of changes over the periods and the
+// files ranked by History Complexity Metric.
+func printHCMReport(periods []changeentropy.Period, scores []changeentropy.Score) {
+	// periods without changes have no entropy but count towards the mean
+	var total float64
+	for _, period := range periods {
+		total += period.Entropy
+	}
+	count := changeentropy.Count(periods)
+	mean := 0.0
+	if count > 0 {
+		mean = total / float64(count)
+	}
+	fmt.Printf("change entropy: %d periods, mean %.4f\n", count, mean)
+	fmt.Println()
+
+	maxNameWidth := len("Filename")
+	for _, score := range scores {
+		maxNameWidth = max(maxNameWidth, len(score.Filename))
+	}
+	fmt.Printf("%-*s | %-7s | %-9s | %-9s | %-9s | %s\n", maxNameWidth, "Filename", "Periods", "HCM", "EDHCM", "LDHCM", "LGDHCM")
+	fmt.Println("---------------------------")
+	for _, score := range scores {
+		fmt.Printf("%-*s | %-7d | %-9.4f | %-9.4f | %-9.4f | %.4f\n",
+			maxNameWidth, score.Filename, score.Periods, score.HCM, score.EDHCM, score.LDHCM, score.LGDHCM)
+	}
+}
+
+// printDecomposition prints how the entropy of changes over authors and
+// files breaks down. H(author|file) is the repository score.
+func printDecomposition(d entropy.Decomposition) {
+	for _, row := range []struct {
+		name  string
+		value float64
+		note  string
+	}{
+		{"H(author)", d.Author, "spread of changes over authors"},
+		{"H(file)", d.File, "spread of changes over files"},
+		{"H(author, file)", d.Joint, "H(file) + H(author | file)"},
+		{"H(author | file)", d.AuthorGivenFile, "score: spread of authors within a file"},
+		{"H(file | author)", d.FileGivenAuthor, "spread of files within an author's work"},
+		{"I(author; file)", d.MutualInformation, "H(author) - H(author | file): how siloed authors are"},
+	} {
+		fmt.Printf("%-16s = %.3f  %s\n", row.name, row.value, row.note)
+	}
+}
+
+// printTree prints a directory tree with the pooled entropy, authors, files
+// and commits of every directory and its lowest-entropy children.
+// Directories cut off by the depth limit are shown as a count.
+func printTree(root *entropy.DirectoryNode) {
+	var printNode func(n *entropy.DirectoryNode, prefix, branch, indent string)
+	printNode = func(n *entropy.DirectoryNode, prefix, branch, indent string) {
+		name := path.Base(n.Path) + "/"
+		if n.Path == "." {
+			name = "."
+		}
+		line := fmt.Sprintf("%s%s%s  entropy %.4f, %d authors, %d files, %d commits",
+			prefix, branch, name, n.Entropy, n.Authors, n.Files, n.Commits)
+		if n.Collapsed > 0 {
+			line += fmt.Sprintf(" [+%d dirs]", n.Collapsed)
+		}
+		if len(n.Lowest) > 0 {
+			lowest := make([]string, len(n.Lowest))
+			for i, child := range n.Lowest {
+				if child.Dir {
+					child.Name += "/"
+				}
+				lowest[i] = fmt.Sprintf("%s %.4f", child.Name, child.Entropy)
+			}
+			line += "; lowest: " + strings.Join(lowest, ", ")
+		}
+		fmt.Println(line)
+
+		for i, child := range n.Children {
+			if i == len(n.Children)-1 {
+				printNode(child, prefix+indent, "└── ", "    ")
+			} else {
+				printNode(child, prefix+indent, "├── ", "│   ")
+			}
+		}
+	}
+	printNode(root, "", "", "")
+}
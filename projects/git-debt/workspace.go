@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"techdebt/components/entropy"
+	"techdebt/components/git"
+	"techdebt/components/workspace"
+)
+
+// repoReport summarizes one repository of a workspace.
+type repoReport struct {
+	Name    string
+	Authors int
+	Files   int
+	Entropy entropy.Decomposition
+	// Score is the calcRepoEntropy score, H(author|file).
+	Score float64
+}
+
+// workspaceReport is the combined analysis of all repositories in a
+// manifest.
+type workspaceReport struct {
+	Repos   []repoReport
+	Authors []workspace.AuthorConcentration
+	// AuthorEntropy is the entropy of the pooled author counts of all
+	// repositories, treating the same person in several repos as one.
+	AuthorEntropy float64
+}
+
+// analyzeRepo runs the history analysis of opts on one repository and
+// returns its summary along with the counts it was computed from. The
+// records are passed on to extra as well.
+func analyzeRepo(ctx context.Context, name string, opts git.HistoryOptions, byLines bool, extra ...accumulator) (repoReport, fileAuthorCounts, error) {
+	authors := make(authorCounts)
+	files := make(fileCounts)
+	fileAuthors := make(fileAuthorCounts)
+	accumulators := append([]accumulator{authors, files, fileAuthors}, extra...)
+	if err := consume(history(ctx, opts, byLines), accumulators...); err != nil {
+		return repoReport{}, nil, err
+	}
+
+	d := entropy.Decompose(fileAuthors)
+	summary := repoReport{
+		Name:    name,
+		Authors: len(authors),
+		Files:   len(files),
+		Entropy: d,
+		Score:   d.AuthorGivenFile,
+	}
+	return summary, fileAuthors, nil
+}
+
+// analyzeWorkspace runs the history analysis of opts on every repository of
+// the manifest and pools the author counts across them.
+func analyzeWorkspace(ctx context.Context, manifest workspace.Manifest, opts git.HistoryOptions, at asOf, byLines bool) (workspaceReport, error) {
+	var report workspaceReport
+	pool := workspace.NewPool()
+
+	for _, repo := range manifest.Repos {
+		repoOpts := opts
+		repoOpts.RepoPath = repo.Path
+		repoOpts.CloneURL = ""
+		repoOpts, _, err := at.apply(ctx, repoOpts)
+		if err != nil {
+			return report, fmt.Errorf("%s: %w", repo.Name, err)
+		}
+
+		summary, fileAuthors, err := analyzeRepo(ctx, repo.Name, repoOpts, byLines)
+		if err != nil {
+			return report, fmt.Errorf("%s: %w", repo.Name, err)
+		}
+		report.Repos = append(report.Repos, summary)
+		pool.Add(repo.Name, fileAuthors)
+	}
+
+	report.Authors = pool.Authors()
+	report.AuthorEntropy = pool.AuthorEntropy()
+
+	return report, nil
+}
+
+// runWorkspace analyzes the repositories of a manifest and prints the
+// combined report.
+func runWorkspace(ctx context.Context, manifestPath string, opts git.HistoryOptions, at asOf, byLines bool) error {
+	manifest, err := workspace.ReadManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	report, err := analyzeWorkspace(ctx, manifest, opts, at, byLines)
+	if err != nil {
+		return err
+	}
+
+	printWorkspaceReport(report)
+	return nil
+}